@@ -1,3 +1,5 @@
+import { createClient } from './rpc-client.js';
+
 const appEl = document.getElementById('app');
 const statusChip = document.getElementById('statusChip');
 const lastUpdated = document.getElementById('lastUpdated');
@@ -5,7 +7,26 @@ const errorText = document.getElementById('errorText');
 
 const tokenInput = document.getElementById('tokenInput');
 const tokenHint = document.getElementById('tokenHint');
+const tokenAccountInput = document.getElementById('tokenAccountInput');
+const tokenAccountList = document.getElementById('tokenAccountList');
+const connectNotionBtn = document.getElementById('connectNotionBtn');
+const oauthClientIdInput = document.getElementById('oauthClientIdInput');
+const oauthAuthorizeUrlInput = document.getElementById('oauthAuthorizeUrlInput');
+const oauthTokenUrlInput = document.getElementById('oauthTokenUrlInput');
+const oauthRedirectPortInput = document.getElementById('oauthRedirectPortInput');
+const oauthClientSecretInput = document.getElementById('oauthClientSecretInput');
+const saveOAuthSecretBtn = document.getElementById('saveOAuthSecretBtn');
+const profileSelect = document.getElementById('profileSelect');
+const switchProfileBtn = document.getElementById('switchProfileBtn');
+const exportProfileBtn = document.getElementById('exportProfileBtn');
+const importProfileBtn = document.getElementById('importProfileBtn');
+const importProfileInput = document.getElementById('importProfileInput');
+const newProfileNameInput = document.getElementById('newProfileNameInput');
+const addProfileBtn = document.getElementById('addProfileBtn');
+const profileTokenAccountInput = document.getElementById('profileTokenAccountInput');
+const profileHint = document.getElementById('profileHint');
 const launchAtLoginInput = document.getElementById('launchAtLoginInput');
+const trayLabelModeInput = document.getElementById('trayLabelModeInput');
 const notionVersionInput = document.getElementById('notionVersionInput');
 
 const tabNav = document.getElementById('tabNav');
@@ -15,19 +36,36 @@ const addDatabaseBtn = document.getElementById('addDatabaseBtn');
 const databaseCardTemplate = document.getElementById('databaseCardTemplate');
 
 const saveConfigBtn = document.getElementById('saveConfigBtn');
+const configErrors = document.getElementById('configErrors');
 const saveTokenBtn = document.getElementById('saveTokenBtn');
 const clearTokenBtn = document.getElementById('clearTokenBtn');
 const openSettingsBtn = document.getElementById('openSettingsBtn');
 const openBrainBtn = document.getElementById('openBrainBtn');
-const brainDatabaseIdInput = document.getElementById('brainDatabaseIdInput');
-const brainTemplateIdInput = document.getElementById('brainTemplateIdInput');
+const brainProfileList = document.getElementById('brainProfileList');
+const addBrainProfileBtn = document.getElementById('addBrainProfileBtn');
+const brainProfileCardTemplate = document.getElementById('brainProfileCardTemplate');
+const brainProfileSelect = document.getElementById('brainProfileSelect');
 const brainBodyInput = document.getElementById('brainBodyInput');
 const brainSubmitBtn = document.getElementById('brainSubmitBtn');
 const brainReloadBtn = document.getElementById('brainReloadBtn');
 const brainOpenTemplateBtn = document.getElementById('brainOpenTemplateBtn');
 const brainOpenCreatedBtn = document.getElementById('brainOpenCreatedBtn');
+const brainDailyReviewBtn = document.getElementById('brainDailyReviewBtn');
 const brainTemplateHint = document.getElementById('brainTemplateHint');
 const brainStatus = document.getElementById('brainStatus');
+const brainNoteList = document.getElementById('brainNoteList');
+const brainNoteEmpty = document.getElementById('brainNoteEmpty');
+const focusBar = document.getElementById('focusBar');
+const focusRemaining = document.getElementById('focusRemaining');
+const focusTitle = document.getElementById('focusTitle');
+const focusToggleBtn = document.getElementById('focusToggleBtn');
+const focusFinishBtn = document.getElementById('focusFinishBtn');
+const focusCancelBtn = document.getElementById('focusCancelBtn');
+const timePeriodInput = document.getElementById('timePeriodInput');
+const timeSummaryEl = document.getElementById('timeSummary');
+const timeHint = document.getElementById('timeHint');
+const exportTimeBtn = document.getElementById('exportTimeBtn');
+const syncTimeBtn = document.getElementById('syncTimeBtn');
 
 const wails = window.wails;
 const appMode = (() => {
@@ -47,10 +85,17 @@ let state = {
   view: appMode === 'settings' ? 'settings' : appMode === 'brain' ? 'brain' : null,
   config: null,
   tokenSet: false,
+  // Notion にトークンを拒否され、DB の取得を止めているアカウント（空文字は既定のトークン）
+  invalidTokenAccounts: [],
   pollTimer: null,
   paneMap: new Map(),
   dbMap: new Map(),
   brainLastCreatedURL: '',
+  brainProfileKey: '',
+  brainDraftTimer: null,
+  focus: null,
+  focusReceivedAt: 0,
+  focusTimer: null,
 };
 
 function runtimeReady() {
@@ -83,13 +128,16 @@ function rpc(action, payload = {}) {
       if (res.ok) {
         resolve(res.data);
       } else {
-        reject(new Error(res.error || 'unknown error'));
+        reject(Object.assign(new Error(res.error || 'unknown error'), { code: res.code || '' }));
       }
     });
     wails.System.invoke(JSON.stringify({ id, action, payload }));
   });
 }
 
+// アクションごとの型付きクライアント（internal/rpc/api から生成）
+const api = createClient(rpc);
+
 function setView(view) {
   if (state.mode === 'brain') {
     state.view = 'brain';
@@ -128,7 +176,10 @@ function setError(message) {
 }
 
 function setStatusChip() {
-  if (state.tokenSet) {
+  if (state.invalidTokenAccounts.length) {
+    statusChip.textContent = 'トークン無効';
+    statusChip.classList.remove('is-connected');
+  } else if (state.tokenSet) {
     statusChip.textContent = '接続準備OK';
     statusChip.classList.add('is-connected');
   } else {
@@ -147,17 +198,27 @@ function formatTime(value) {
     .padStart(2, '0')}`;
 }
 
+function formatDue(value) {
+  // 日付のみの期限（YYYY-MM-DD）はタイムゾーン変換せずにそのまま表示する
+  if (/^\d{4}-\d{2}-\d{2}$/.test(value)) {
+    return value.slice(5).replace('-', '/');
+  }
+  const date = new Date(value);
+  if (Number.isNaN(date.getTime())) return value;
+  return `${date.getMonth() + 1}/${date.getDate()} ${formatTime(value)}`;
+}
+
 function defaultDatabaseName(kind) {
   return kind === 'habit' ? '習慣' : 'タスク';
 }
 
 const defaultHabitDays = '日,月,火,水,木,金,土';
 
-function generateKey() {
+function generateKey(prefix = 'db') {
   if (typeof crypto !== 'undefined' && crypto.randomUUID) {
-    return `db-${crypto.randomUUID().slice(0, 8)}`;
+    return `${prefix}-${crypto.randomUUID().slice(0, 8)}`;
   }
-  return `db-${Date.now().toString(36)}-${Math.random().toString(36).slice(2, 6)}`;
+  return `${prefix}-${Date.now().toString(36)}-${Math.random().toString(36).slice(2, 6)}`;
 }
 
 function pickDefaultView() {
@@ -277,6 +338,11 @@ function createDatabaseCard(db) {
   card.querySelector('.db-status-in-progress').value = db.status_in_progress || '';
   card.querySelector('.db-status-done').value = db.status_done || '';
   card.querySelector('.db-status-paused').value = db.status_paused || '';
+  card.querySelector('.db-due-property').value = db.due_property_name || '';
+  card.querySelector('.db-assignee-property').value = db.assignee_property_name || '';
+  card.querySelector('.db-time-property').value = db.time_property_name || '';
+  card.querySelector('.db-time-total-property').value = db.time_total_property_name || '';
+  card.querySelector('.db-account').value = db.account || '';
   card.querySelector('.db-checkbox-property').value = db.checkbox_property_name || defaultHabitDays;
 
   applyDatabaseKind(card, kindSelect.value);
@@ -339,6 +405,11 @@ function collectDatabases() {
       status_in_progress: card.querySelector('.db-status-in-progress').value.trim(),
       status_done: card.querySelector('.db-status-done').value.trim(),
       status_paused: card.querySelector('.db-status-paused').value.trim(),
+      due_property_name: card.querySelector('.db-due-property').value.trim(),
+      assignee_property_name: card.querySelector('.db-assignee-property').value.trim(),
+      time_property_name: card.querySelector('.db-time-property').value.trim(),
+      time_total_property_name: card.querySelector('.db-time-total-property').value.trim(),
+      account: card.querySelector('.db-account').value.trim(),
       checkbox_property_name:
         card.querySelector('.db-checkbox-property').value.trim() || defaultHabitDays,
     };
@@ -367,6 +438,12 @@ function renderTasks(listEl, emptyEl, tasks, dbKey) {
     const meta = document.createElement('div');
     meta.className = 'task-meta';
     meta.innerHTML = `<span>更新 ${formatTime(task.last_edited_time)}</span>`;
+    if (task.due) {
+      const due = document.createElement('span');
+      due.className = task.overdue ? 'task-due overdue' : 'task-due';
+      due.textContent = `${task.overdue ? '期限切れ' : '期限'} ${formatDue(task.due)}`;
+      meta.appendChild(due);
+    }
 
     const actions = document.createElement('div');
     actions.className = 'task-actions';
@@ -385,6 +462,13 @@ function renderTasks(listEl, emptyEl, tasks, dbKey) {
       actions.appendChild(doneBtn);
     }
 
+    const focusBtn = document.createElement('button');
+    focusBtn.className = 'btn ghost focus-start';
+    focusBtn.textContent = '集中';
+    focusBtn.disabled = Boolean(state.focus);
+    focusBtn.addEventListener('click', () => startFocus(dbKey, task.id));
+    actions.appendChild(focusBtn);
+
     if (canPause) {
       const pauseBtn = document.createElement('button');
       pauseBtn.className = 'btn ghost';
@@ -456,10 +540,10 @@ async function refreshDatabaseView(dbKey, force = false) {
   try {
     setError('');
     if (db.kind === 'habit') {
-      const habits = await rpc('getHabits', { database_key: dbKey, force_refresh: force });
+      const habits = await api.getHabits({ database_key: dbKey, force_refresh: force });
       renderHabits(pane.listEl, pane.emptyEl, habits, dbKey);
     } else {
-      const tasks = await rpc('getTasks', { database_key: dbKey, force_refresh: force });
+      const tasks = await api.getTasks({ database_key: dbKey, force_refresh: force });
       renderTasks(pane.listEl, pane.emptyEl, tasks, dbKey);
     }
     lastUpdated.textContent = `更新 ${formatTime(new Date().toISOString())}`;
@@ -478,18 +562,174 @@ function refreshActiveView(force = false) {
 async function updateTaskStatus(taskID, action, dbKey) {
   try {
     setError('');
-    await rpc('updateStatus', { database_key: dbKey, task_id: taskID, action });
+    await api.updateStatus({ database_key: dbKey, task_id: taskID, action });
     await refreshDatabaseView(dbKey, true);
   } catch (err) {
     setError(err.message);
   }
 }
 
+function applyFocus(session) {
+  state.focus = session || null;
+  state.focusReceivedAt = Date.now();
+  if (state.focusTimer) {
+    clearInterval(state.focusTimer);
+    state.focusTimer = null;
+  }
+  renderFocus();
+  if (state.focus && !state.focus.paused) {
+    state.focusTimer = setInterval(renderFocus, 1000);
+  }
+  document.querySelectorAll('.task-card .focus-start').forEach((btn) => {
+    btn.disabled = Boolean(state.focus);
+  });
+}
+
+function renderFocus() {
+  const session = state.focus;
+  if (!focusBar) return;
+  focusBar.hidden = !session;
+  if (!session) return;
+  // remaining_seconds は取得時点の値なので、計測中は受信からの経過分を差し引く
+  let remaining = session.remaining_seconds;
+  if (!session.paused) {
+    remaining -= Math.floor((Date.now() - state.focusReceivedAt) / 1000);
+  }
+  remaining = Math.max(0, remaining);
+  const minutes = Math.floor(remaining / 60).toString().padStart(2, '0');
+  const seconds = (remaining % 60).toString().padStart(2, '0');
+  focusRemaining.textContent = `${session.paused ? '⏸' : '🍅'} ${minutes}:${seconds}`;
+  focusTitle.textContent = session.task_title || '(無題)';
+  focusToggleBtn.textContent = session.paused ? '再開' : '一時停止';
+}
+
+async function startFocus(dbKey, taskID) {
+  try {
+    setError('');
+    applyFocus(await api.startFocus({ database_key: dbKey, task_id: taskID }));
+  } catch (err) {
+    setError(err.message);
+  }
+}
+
+async function toggleFocus() {
+  try {
+    setError('');
+    const session = state.focus?.paused ? await api.resumeFocus() : await api.pauseFocus();
+    applyFocus(session);
+  } catch (err) {
+    setError(err.message);
+  }
+}
+
+async function finishFocus() {
+  try {
+    setError('');
+    focusFinishBtn.disabled = true;
+    await api.finishFocus();
+    applyFocus(null);
+  } catch (err) {
+    setError(err.message);
+  } finally {
+    focusFinishBtn.disabled = false;
+  }
+}
+
+async function cancelFocus() {
+  if (!confirm('記録せずに集中セッションを破棄しますか？')) {
+    return;
+  }
+  try {
+    setError('');
+    await api.cancelFocus();
+    applyFocus(null);
+  } catch (err) {
+    setError(err.message);
+  }
+}
+
+function formatMinutes(seconds) {
+  const minutes = Math.round(seconds / 60);
+  if (minutes < 60) {
+    return `${minutes} 分`;
+  }
+  return `${Math.floor(minutes / 60)} 時間 ${minutes % 60} 分`;
+}
+
+function formatDate(date) {
+  const pad = (n) => String(n).padStart(2, '0');
+  return `${date.getFullYear()}-${pad(date.getMonth() + 1)}-${pad(date.getDate())}`;
+}
+
+async function loadTimeSummary() {
+  if (!timeSummaryEl) {
+    return;
+  }
+  try {
+    const summary = await api.getTimeSummary({ period: timePeriodInput.value, date: '' });
+    timeSummaryEl.innerHTML = '';
+    const databases = summary.databases || [];
+    if (databases.length === 0) {
+      timeHint.textContent = '記録はありません';
+      return;
+    }
+    timeHint.textContent = `合計 ${formatMinutes(summary.total_seconds)}`;
+    databases.forEach((db) => {
+      const group = document.createElement('div');
+      group.className = 'time-group';
+      const header = document.createElement('div');
+      header.className = 'time-row time-db';
+      header.innerHTML = '<span class="time-name"></span><span class="time-value"></span>';
+      header.querySelector('.time-name').textContent = db.name;
+      header.querySelector('.time-value').textContent = formatMinutes(db.seconds);
+      group.appendChild(header);
+      (db.tasks || []).forEach((task) => {
+        const row = document.createElement('div');
+        row.className = 'time-row';
+        row.innerHTML = '<span class="time-name"></span><span class="time-value"></span>';
+        row.querySelector('.time-name').textContent = task.title;
+        row.querySelector('.time-value').textContent = formatMinutes(task.seconds);
+        group.appendChild(row);
+      });
+      timeSummaryEl.appendChild(group);
+    });
+  } catch (err) {
+    setError(err.message);
+  }
+}
+
+async function exportTime() {
+  const summaryFrom = new Date();
+  if (timePeriodInput.value === 'week') {
+    summaryFrom.setDate(summaryFrom.getDate() - ((summaryFrom.getDay() + 6) % 7));
+  }
+  try {
+    setError('');
+    const path = await api.exportTimeCSV({ from: formatDate(summaryFrom), to: formatDate(new Date()) });
+    timeHint.textContent = `書き出しました: ${path}`;
+  } catch (err) {
+    setError(err.message);
+  }
+}
+
+async function syncTimeTotals() {
+  try {
+    setError('');
+    syncTimeBtn.disabled = true;
+    const updated = await api.syncTimeTotals();
+    timeHint.textContent = `${updated} 件のページを更新しました`;
+  } catch (err) {
+    setError(err.message);
+  } finally {
+    syncTimeBtn.disabled = false;
+  }
+}
+
 async function updateHabitCheck(dbKey, taskID, checkbox) {
   try {
     setError('');
     checkbox.disabled = true;
-    await rpc('updateHabitCheck', { database_key: dbKey, task_id: taskID, checked: true });
+    await api.updateHabitCheck({ database_key: dbKey, task_id: taskID, checked: true });
     await refreshDatabaseView(dbKey, true);
   } catch (err) {
     checkbox.disabled = false;
@@ -501,7 +741,7 @@ async function updateHabitCheck(dbKey, taskID, checkbox) {
 async function openURL(url) {
   try {
     setError('');
-    await rpc('openURL', { url });
+    await api.openURL({ url });
   } catch (err) {
     setError(err.message);
   }
@@ -510,7 +750,7 @@ async function openURL(url) {
 async function openSettingsWindow() {
   try {
     setError('');
-    await rpc('openSettingsWindow');
+    await api.openSettingsWindow();
   } catch (err) {
     setError(err.message);
   }
@@ -519,20 +759,212 @@ async function openSettingsWindow() {
 async function openBrainWindow() {
   try {
     setError('');
-    await rpc('openBrainWindow');
+    await api.openBrainWindow();
   } catch (err) {
     setError(err.message);
   }
 }
 
+function renderBrainProfileSettings(profiles) {
+  if (!brainProfileList) {
+    return;
+  }
+  brainProfileList.innerHTML = '';
+  (profiles || []).forEach((profile) => {
+    brainProfileList.appendChild(createBrainProfileCard(profile));
+  });
+}
+
+function formatBrainProperties(properties) {
+  return Object.entries(properties || {})
+    .map(([name, value]) => `${name}=${value}`)
+    .join('\n');
+}
+
+function parseBrainProperties(text) {
+  const out = {};
+  (text || '').split('\n').forEach((line) => {
+    const index = line.indexOf('=');
+    if (index <= 0) {
+      return;
+    }
+    const name = line.slice(0, index).trim();
+    if (name) {
+      out[name] = line.slice(index + 1).trim();
+    }
+  });
+  return out;
+}
+
+function createBrainProfileCard(profile) {
+  const card = brainProfileCardTemplate.content.firstElementChild.cloneNode(true);
+  const key = profile.key || generateKey('brain');
+  card.dataset.key = key;
+  card.querySelector('.bp-key').textContent = `#${key}`;
+  card.querySelector('.bp-name-input').value = profile.name || '';
+  card.querySelector('.bp-database-id').value = profile.database_id || '';
+  card.querySelector('.bp-template-id').value = profile.template_page_id || '';
+  card.querySelector('.bp-account').value = profile.account || '';
+  card.querySelector('.bp-title-prefix').value = profile.title_prefix || '';
+  card.querySelector('.bp-title-date').checked = Boolean(profile.title_date);
+  card.querySelector('.bp-properties').value = formatBrainProperties(profile.properties);
+  card.querySelector('.bp-delete-btn').addEventListener('click', () => {
+    if (!confirm('この Brain プロファイルを削除しますか？')) {
+      return;
+    }
+    card.remove();
+  });
+  return card;
+}
+
+function collectBrainProfiles() {
+  if (!brainProfileList) {
+    return state.config?.brain_profiles || [];
+  }
+  const cards = brainProfileList.querySelectorAll('.brain-profile-card');
+  return Array.from(cards).map((card) => {
+    const key = card.dataset.key || generateKey('brain');
+    card.dataset.key = key;
+    return {
+      key,
+      name: card.querySelector('.bp-name-input').value.trim(),
+      database_id: card.querySelector('.bp-database-id').value.trim(),
+      template_page_id: card.querySelector('.bp-template-id').value.trim(),
+      account: card.querySelector('.bp-account').value.trim(),
+      title_prefix: card.querySelector('.bp-title-prefix').value.trim(),
+      title_date: card.querySelector('.bp-title-date').checked,
+      properties: parseBrainProperties(card.querySelector('.bp-properties').value),
+    };
+  });
+}
+
+function addBrainProfile() {
+  brainProfileList.appendChild(createBrainProfileCard({ key: generateKey('brain') }));
+}
+
+function renderBrainProfileSelect(profiles) {
+  if (!brainProfileSelect) {
+    return;
+  }
+  const current = brainProfileSelect.value;
+  brainProfileSelect.innerHTML = '';
+  (profiles || []).forEach((profile) => {
+    const option = document.createElement('option');
+    option.value = profile.key;
+    option.textContent = profile.name || profile.key;
+    brainProfileSelect.appendChild(option);
+  });
+  if (current && (profiles || []).some((profile) => profile.key === current)) {
+    brainProfileSelect.value = current;
+  }
+}
+
+function selectedBrainProfile() {
+  const profiles = state.config?.brain_profiles || [];
+  const key = brainProfileSelect?.value || '';
+  return profiles.find((profile) => profile.key === key) || profiles[0] || null;
+}
+
 function normalizeNotionId(value) {
   return (value || '').replace(/-/g, '').trim();
 }
 
+// brainDraftDelay は入力が止まってから下書きを送るまでの待ち時間（ms）。
+const brainDraftDelay = 500;
+
+function scheduleBrainDraft() {
+  if (state.brainDraftTimer) {
+    clearTimeout(state.brainDraftTimer);
+  }
+  const profile = state.brainProfileKey;
+  const body = brainBodyInput.value || '';
+  state.brainDraftTimer = setTimeout(() => {
+    state.brainDraftTimer = null;
+    api.saveBrainDraft({ profile, body }).catch((err) => setError(err.message));
+  }, brainDraftDelay);
+}
+
+// flushBrainDraft は送信待ちの下書きをすぐに送る（プロファイル切り替え・ウィンドウ非表示時）。
+function flushBrainDraft() {
+  if (!state.brainDraftTimer) {
+    return;
+  }
+  clearTimeout(state.brainDraftTimer);
+  state.brainDraftTimer = null;
+  api
+    .saveBrainDraft({ profile: state.brainProfileKey, body: brainBodyInput.value || '' })
+    .catch((err) => setError(err.message));
+}
+
+function cancelBrainDraft() {
+  if (state.brainDraftTimer) {
+    clearTimeout(state.brainDraftTimer);
+    state.brainDraftTimer = null;
+  }
+}
+
+async function changeBrainProfile() {
+  flushBrainDraft();
+  await loadBrainTemplate();
+}
+
+// reloadBrainTemplate はテンプレートを読み込み直し、入力中の下書きを捨てる。
+async function reloadBrainTemplate() {
+  if (brainBodyInput.value && !confirm('入力中の本文を破棄してテンプレートを読み込み直しますか？')) {
+    return;
+  }
+  cancelBrainDraft();
+  try {
+    await api.saveBrainDraft({ profile: state.brainProfileKey, body: '' });
+  } catch (err) {
+    setError(err.message);
+  }
+  await loadBrainTemplate();
+}
+
+async function loadBrainNotes() {
+  if (!brainNoteList) {
+    return;
+  }
+  let notes = [];
+  try {
+    notes = (await api.getBrainNotes()) || [];
+  } catch (err) {
+    setError(err.message);
+    return;
+  }
+  const names = new Map((state.config?.brain_profiles || []).map((p) => [p.key, p.name]));
+  brainNoteList.innerHTML = '';
+  notes.forEach((note) => {
+    const row = document.createElement('div');
+    row.className = 'brain-note';
+    const text = document.createElement('span');
+    text.className = 'brain-note-text';
+    const profileName = names.get(note.profile) || note.profile;
+    text.textContent = `${profileName ? `[${profileName}] ` : ''}${note.excerpt || '（本文なし）'}`;
+    const time = document.createElement('span');
+    time.className = 'brain-note-time';
+    time.textContent = formatDue(note.created_at);
+    const open = document.createElement('button');
+    open.className = 'btn ghost';
+    open.type = 'button';
+    open.textContent = '開く';
+    open.disabled = !note.url;
+    open.addEventListener('click', () => openURL(note.url));
+    row.append(text, time, open);
+    brainNoteList.appendChild(row);
+  });
+  if (brainNoteEmpty) {
+    brainNoteEmpty.hidden = notes.length > 0;
+  }
+}
+
 async function loadBrainTemplate() {
   if (!brainBodyInput) {
     return;
   }
+  const profile = selectedBrainProfile()?.key || '';
+  state.brainProfileKey = profile;
   if (brainTemplateHint) {
     brainTemplateHint.textContent = 'テンプレート読み込み中...';
   }
@@ -543,10 +975,20 @@ async function loadBrainTemplate() {
     brainOpenCreatedBtn.disabled = true;
   }
   try {
-    const tpl = await rpc('getBrainTemplate');
+    const draft = await api.getBrainDraft({ profile });
+    if (draft?.body) {
+      brainBodyInput.value = draft.body;
+      if (brainTemplateHint) {
+        brainTemplateHint.textContent = '前回の下書きを復元しました（テンプレ再読み込みで破棄）';
+      }
+      return;
+    }
+    const tpl = await api.getBrainTemplate({ profile });
     brainBodyInput.value = tpl?.body || '';
     if (brainTemplateHint) {
-      brainTemplateHint.textContent = 'テンプレートを読み込みました';
+      brainTemplateHint.textContent = tpl?.has_placeholder
+        ? 'テンプレートの {{body}} の位置に本文を差し込みます'
+        : 'テンプレートを読み込みました';
     }
   } catch (err) {
     setError(err.message);
@@ -557,10 +999,9 @@ async function loadBrainTemplate() {
 }
 
 async function openBrainTemplate() {
-  const raw = brainTemplateIdInput?.value || state.config?.brain_template_page_id || '';
-  const id = normalizeNotionId(raw);
+  const id = normalizeNotionId(selectedBrainProfile()?.template_page_id);
   if (!id) {
-    setError('Brain Template Page ID を入力してください');
+    setError('Brain プロファイルの Template Page ID を設定してください');
     return;
   }
   await openURL(`https://www.notion.so/${id}`);
@@ -571,6 +1012,8 @@ async function createBrainPage() {
     return;
   }
   const body = brainBodyInput.value || '';
+  // 登録後に古い下書きが書き戻されないよう、送信待ちを取り消す
+  cancelBrainDraft();
   if (brainSubmitBtn) {
     brainSubmitBtn.disabled = true;
   }
@@ -581,7 +1024,7 @@ async function createBrainPage() {
     brainOpenCreatedBtn.disabled = true;
   }
   try {
-    const page = await rpc('createBrainPage', { body });
+    const page = await api.createBrainPage({ profile: state.brainProfileKey, body });
     state.brainLastCreatedURL = page?.url || '';
     if (brainStatus) {
       brainStatus.textContent = page?.url ? '登録しました（Notionで開けます）' : '登録しました';
@@ -589,8 +1032,11 @@ async function createBrainPage() {
     if (brainOpenCreatedBtn) {
       brainOpenCreatedBtn.disabled = !page?.url;
     }
+    await loadBrainNotes();
   } catch (err) {
     setError(err.message);
+    // 失敗した本文は下書きとして残す
+    scheduleBrainDraft();
     if (brainStatus) {
       brainStatus.textContent = '登録に失敗しました';
     }
@@ -601,50 +1047,313 @@ async function createBrainPage() {
   }
 }
 
+async function createDailyReview() {
+  brainDailyReviewBtn.disabled = true;
+  if (brainStatus) {
+    brainStatus.textContent = '日次レビューを作成中...';
+  }
+  try {
+    const page = await api.createDailyReview();
+    state.brainLastCreatedURL = page?.url || '';
+    if (brainStatus) {
+      brainStatus.textContent = '日次レビューを作成しました';
+    }
+    if (brainOpenCreatedBtn) {
+      brainOpenCreatedBtn.disabled = !page?.url;
+    }
+  } catch (err) {
+    setError(err.message);
+    if (brainStatus) {
+      brainStatus.textContent = '日次レビューの作成に失敗しました';
+    }
+  } finally {
+    brainDailyReviewBtn.disabled = false;
+  }
+}
+
 async function loadConfig() {
-  const cfg = await rpc('getConfig');
+  const cfg = await api.getConfig();
   state.config = cfg;
+  renderConfigErrors([]);
   launchAtLoginInput.checked = Boolean(cfg.launch_at_login);
+  trayLabelModeInput.value = cfg.tray_label_mode || 'none';
   notionVersionInput.value = cfg.notion_version || '';
-  if (brainDatabaseIdInput) {
-    brainDatabaseIdInput.value = cfg.brain_database_id || '';
-  }
-  if (brainTemplateIdInput) {
-    brainTemplateIdInput.value = cfg.brain_template_page_id || '';
-  }
+  renderOAuthSettings(cfg.oauth || {});
+  renderProfileSettings(cfg);
+  renderTokenAccounts(cfg);
+  renderBrainProfileSettings(cfg.brain_profiles || []);
+  renderBrainProfileSelect(cfg.brain_profiles || []);
   renderDatabaseSettings(cfg.databases || []);
   renderTabsAndPanes();
 }
 
-async function saveConfig() {
+// 検証エラーの項目名（databases[i] / brain_profiles[i] の下）と、設定画面の入力欄の対応。
+const databaseFieldSelectors = {
+  key: '.db-name-input',
+  name: '.db-name-input',
+  kind: '.db-kind-select',
+  database_id: '.db-database-id',
+  data_source_id: '.db-data-source-id',
+  title_property_name: '.db-title-property',
+  status_property_name: '.db-status-property',
+  status_property_type: '.db-status-type',
+  status_in_progress: '.db-status-in-progress',
+  status_done: '.db-status-done',
+  status_paused: '.db-status-paused',
+};
+const brainProfileFieldSelectors = {
+  key: '.bp-name-input',
+  database_id: '.bp-database-id',
+  template_page_id: '.bp-template-id',
+};
+
+function invalidFieldElement(path) {
+  const match = /^(databases|brain_profiles)\[(\d+)\]\.(\w+)$/.exec(path);
+  if (match) {
+    const [, list, index, field] = match;
+    const container = list === 'databases' ? databaseList : brainProfileList;
+    const selectors = list === 'databases' ? databaseFieldSelectors : brainProfileFieldSelectors;
+    const card = container?.children[Number(index)];
+    return card && selectors[field] ? card.querySelector(selectors[field]) : null;
+  }
+  if (/^profiles\[\d+\]\.name$/.test(path)) {
+    return profileSelect;
+  }
+  switch (path) {
+    case 'notion_version':
+      return notionVersionInput;
+    case 'tray_label_mode':
+      return trayLabelModeInput;
+    case 'active_profile':
+      return profileSelect;
+    case 'oauth.authorize_url':
+      return oauthAuthorizeUrlInput;
+    case 'oauth.token_url':
+      return oauthTokenUrlInput;
+    case 'oauth.redirect_port':
+      return oauthRedirectPortInput;
+    default:
+      return null;
+  }
+}
+
+// renderConfigErrors は検証エラーを一覧にし、該当する入力欄を強調する。空なら消す。
+function renderConfigErrors(errors) {
+  document.querySelectorAll('.is-invalid').forEach((el) => el.classList.remove('is-invalid'));
+  if (!configErrors) {
+    return;
+  }
+  configErrors.innerHTML = '';
+  (errors || []).forEach((fieldError) => {
+    const item = document.createElement('li');
+    item.textContent = `${fieldError.path}: ${fieldError.message}`;
+    configErrors.appendChild(item);
+    invalidFieldElement(fieldError.path)?.classList.add('is-invalid');
+  });
+  configErrors.hidden = !errors || errors.length === 0;
+}
+
+// saveConfig は設定画面の内容を保存する。profiles は追加したプロファイルなど、画面の外で組み立てた値で上書きするときに渡す。
+async function saveConfig(profiles = collectProfiles()) {
   const cfg = {
     ...state.config,
+    ...profiles,
     databases: collectDatabases(),
     launch_at_login: launchAtLoginInput.checked,
+    tray_label_mode: trayLabelModeInput.value,
     notion_version: notionVersionInput.value.trim(),
-    brain_database_id: brainDatabaseIdInput?.value.trim() || '',
-    brain_template_page_id: brainTemplateIdInput?.value.trim() || '',
+    brain_profiles: collectBrainProfiles(),
+    oauth: collectOAuthSettings(),
   };
-  await rpc('saveConfig', cfg);
+  try {
+    const errors = await api.validateConfig(cfg);
+    renderConfigErrors(errors);
+    if (errors?.length) {
+      setError('設定に誤りがあります');
+      return;
+    }
+    // 読み込んだときの revision を付けて送り、その後に書き換えられた設定は上書きしない
+    cfg.revision = await api.saveConfig(cfg);
+  } catch (err) {
+    if (err.code !== 'config_conflict') {
+      setError(err.message);
+      return;
+    }
+    // 他のプロセス（CLI や手動編集）が書き換えた設定を上書きしない
+    if (confirm('設定ファイルが外部で変更されています。読み込み直しますか？（この画面の変更は破棄されます）')) {
+      await loadConfig();
+      setError('');
+    } else {
+      setError('設定ファイルが外部で変更されたため保存しませんでした');
+    }
+    return;
+  }
   state.config = cfg;
+  renderProfileSettings(cfg);
   renderTabsAndPanes();
   const nextView = state.dbMap.has(state.view) ? state.view : pickDefaultView();
   setView(nextView);
   setError('');
 }
 
+function renderProfileSettings(cfg) {
+  if (!profileSelect) {
+    return;
+  }
+  const profiles = cfg.profiles || [];
+  profileSelect.innerHTML = '';
+  if (profiles.length === 0) {
+    const option = document.createElement('option');
+    option.value = '';
+    option.textContent = 'プロファイルなし';
+    profileSelect.appendChild(option);
+  }
+  profiles.forEach((profile) => {
+    const option = document.createElement('option');
+    option.value = profile.name;
+    option.textContent = profile.name === cfg.active_profile ? `${profile.name}（使用中）` : profile.name;
+    option.selected = profile.name === cfg.active_profile;
+    profileSelect.appendChild(option);
+  });
+  profileSelect.disabled = profiles.length === 0;
+  switchProfileBtn.disabled = profiles.length === 0;
+  exportProfileBtn.disabled = profiles.length === 0;
+  const active = profiles.find((profile) => profile.name === cfg.active_profile);
+  profileTokenAccountInput.value = active?.token_account || '';
+}
+
+// collectProfiles は有効なプロファイルのトークンの参照を画面の値にしたプロファイル一覧を返す。
+// プロファイルがないままトークンの参照を入れた場合は、今の設定を既定のプロファイルにする。
+function collectProfiles() {
+  const profiles = (state.config?.profiles || []).map((profile) => ({ ...profile }));
+  let activeProfile = state.config?.active_profile || '';
+  const account = profileTokenAccountInput ? profileTokenAccountInput.value.trim() : '';
+  const active = profiles.find((profile) => profile.name === activeProfile);
+  if (active) {
+    active.token_account = account;
+  } else if (account) {
+    activeProfile = 'default';
+    profiles.push({ name: activeProfile, token_account: account, databases: [] });
+  }
+  return { profiles, active_profile: activeProfile };
+}
+
+async function addProfile() {
+  const name = newProfileNameInput.value.trim();
+  if (!name) {
+    setError('プロファイル名を入力してください');
+    return;
+  }
+  const next = collectProfiles();
+  if (next.profiles.some((profile) => profile.name === name)) {
+    setError(`プロファイル「${name}」はすでにあります`);
+    return;
+  }
+  if (next.profiles.length === 0) {
+    // 今の DB は既定のプロファイルとして残す（DB は保存時に最上位の値が写される）
+    next.active_profile = 'default';
+    next.profiles.push({ name: next.active_profile, databases: [] });
+  }
+  next.profiles.push({ name, databases: [] });
+  await saveConfig(next);
+  if (state.config.profiles?.some((profile) => profile.name === name)) {
+    newProfileNameInput.value = '';
+    profileHint.textContent = `プロファイル「${name}」を追加しました。切り替えると DB を設定できます`;
+  }
+}
+
+async function switchProfile() {
+  const name = profileSelect.value;
+  if (!name || name === state.config?.active_profile) {
+    return;
+  }
+  try {
+    setError('');
+    await api.switchProfile({ name });
+    await loadConfig();
+    await refreshTokenStatus();
+    profileHint.textContent = `プロファイル「${name}」に切り替えました`;
+  } catch (err) {
+    setError(err.message);
+  }
+}
+
+async function exportProfile() {
+  try {
+    setError('');
+    const path = await api.exportProfile({ name: profileSelect.value });
+    profileHint.textContent = `書き出しました: ${path}`;
+  } catch (err) {
+    setError(err.message);
+  }
+}
+
+async function importProfile(file) {
+  try {
+    setError('');
+    const bundle = JSON.parse(await file.text());
+    const name = await api.importProfile(bundle);
+    await loadConfig();
+    profileHint.textContent = `プロファイル「${name}」を読み込みました`;
+  } catch (err) {
+    setError(err instanceof SyntaxError ? 'JSON として読み込めませんでした' : err.message);
+  } finally {
+    importProfileInput.value = '';
+  }
+}
+
+// renderTokenAccounts は DB・プロファイル・Brain プロファイルが参照するアカウント ID を入力候補にする。
+function renderTokenAccounts(cfg) {
+  if (!tokenAccountList) {
+    return;
+  }
+  const accounts = new Set();
+  (cfg.databases || []).forEach((db) => db.account && accounts.add(db.account));
+  (cfg.profiles || []).forEach((profile) => profile.token_account && accounts.add(profile.token_account));
+  (cfg.brain_profiles || []).forEach((profile) => profile.account && accounts.add(profile.account));
+  tokenAccountList.innerHTML = '';
+  [...accounts].sort().forEach((account) => {
+    const option = document.createElement('option');
+    option.value = account;
+    tokenAccountList.appendChild(option);
+  });
+}
+
+function selectedTokenAccount() {
+  return tokenAccountInput ? tokenAccountInput.value.trim() : '';
+}
+
 async function refreshTokenStatus() {
-  const tokenSet = await rpc('getTokenStatus');
-  state.tokenSet = Boolean(tokenSet);
-  tokenHint.textContent = tokenSet ? '保存済み' : '未保存';
-  if (tokenSet) {
+  const account = selectedTokenAccount();
+  const tokenSet = await api.getTokenStatus({ account });
+  let status = tokenSet ? '保存済み' : '未保存';
+  let valid = tokenSet;
+  // 設定画面では保存済みのトークンが今も有効かを Notion に確認する
+  if (tokenSet && state.mode === 'settings') {
+    try {
+      status = `保存済み: ${formatTokenIdentity(await api.verifyToken({ account }))}`;
+    } catch (err) {
+      if (err.code === 'token_invalid') {
+        status = '無効なトークンです。新しいトークンを保存してください';
+        valid = false;
+      } else {
+        status = `保存済み（確認できませんでした: ${err.message}）`;
+      }
+    }
+  }
+  tokenHint.textContent = account ? `${account}: ${status}` : status;
+  if (valid) {
     tokenInput.value = '●●●●●●●●●●●●';
     tokenInput.disabled = true;
   } else {
     tokenInput.value = '';
     tokenInput.disabled = false;
   }
-  setStatusChip();
+  // ステータス表示はプロファイルのトークンを基準にする
+  if (!account) {
+    state.tokenSet = Boolean(tokenSet);
+    setStatusChip();
+  }
 }
 
 async function saveToken() {
@@ -653,12 +1362,79 @@ async function saveToken() {
     setError('トークンが空です');
     return;
   }
-  await rpc('setToken', { token });
-  await refreshTokenStatus();
+  try {
+    const identity = await api.setToken({ token, account: selectedTokenAccount() });
+    setError('');
+    await refreshTokenStatus();
+    tokenHint.textContent = `保存しました: ${formatTokenIdentity(identity)}`;
+  } catch (err) {
+    setError(err.code === 'token_invalid' ? 'Notion がトークンを拒否しました。値を確認してください' : err.message);
+  }
+}
+
+function renderOAuthSettings(oauth) {
+  if (!oauthClientIdInput) {
+    return;
+  }
+  oauthClientIdInput.value = oauth.client_id || '';
+  oauthAuthorizeUrlInput.value = oauth.authorize_url || '';
+  oauthTokenUrlInput.value = oauth.token_url || '';
+  oauthRedirectPortInput.value = oauth.redirect_port ? String(oauth.redirect_port) : '';
+}
+
+function collectOAuthSettings() {
+  if (!oauthClientIdInput) {
+    return state.config?.oauth;
+  }
+  return {
+    client_id: oauthClientIdInput.value.trim(),
+    authorize_url: oauthAuthorizeUrlInput.value.trim(),
+    token_url: oauthTokenUrlInput.value.trim(),
+    redirect_port: Number(oauthRedirectPortInput.value) || 0,
+  };
+}
+
+// connectNotion はブラウザで Notion の認可画面を開き、許可されたらトークンを保存する。
+async function connectNotion() {
+  if (!state.config?.oauth?.client_id) {
+    setError('OAuth のクライアント ID を入力して設定を保存してください');
+    return;
+  }
+  const account = selectedTokenAccount();
+  connectNotionBtn.disabled = true;
+  tokenHint.textContent = 'ブラウザで Notion への接続を許可してください…';
+  try {
+    const workspace = await api.connectNotion({ account });
+    setError('');
+    await refreshTokenStatus();
+    tokenHint.textContent = `接続しました: ${workspace.name || 'Notion'}`;
+  } catch (err) {
+    setError(err.message);
+    await refreshTokenStatus();
+  } finally {
+    connectNotionBtn.disabled = false;
+  }
+}
+
+async function saveOAuthSecret() {
+  try {
+    await api.setOAuthClientSecret({ secret: oauthClientSecretInput.value.trim() });
+    oauthClientSecretInput.value = '';
+    setError('');
+    tokenHint.textContent = 'クライアントシークレットを保存しました';
+  } catch (err) {
+    setError(err.message);
+  }
+}
+
+// formatTokenIdentity はインテグレーション名とワークスペース名を表示用にまとめる。
+function formatTokenIdentity(identity) {
+  const name = identity?.name || 'インテグレーション';
+  return identity?.workspace_name ? `${name}（${identity.workspace_name}）` : name;
 }
 
 async function clearToken() {
-  await rpc('clearToken');
+  await api.clearToken({ account: selectedTokenAccount() });
   await refreshTokenStatus();
 }
 
@@ -669,7 +1445,8 @@ async function resolveDataSource(card) {
     return;
   }
   try {
-    const id = await rpc('resolveDataSourceID', { database_id: databaseID });
+    const account = card.querySelector('.db-account').value.trim();
+    const id = await api.resolveDataSourceID({ database_id: databaseID, account });
     card.querySelector('.db-data-source-id').value = id || '';
   } catch (err) {
     setError(err.message);
@@ -683,7 +1460,8 @@ async function resolveTitleProperty(card) {
     return;
   }
   try {
-    const name = await rpc('resolveTitlePropertyName', { database_id: databaseID });
+    const account = card.querySelector('.db-account').value.trim();
+    const name = await api.resolveTitlePropertyName({ database_id: databaseID, account });
     card.querySelector('.db-title-property').value = name || '';
   } catch (err) {
     setError(err.message);
@@ -729,9 +1507,16 @@ function bindUI() {
     }
   });
 
-  saveConfigBtn.addEventListener('click', saveConfig);
+  saveConfigBtn.addEventListener('click', () => saveConfig());
   saveTokenBtn.addEventListener('click', saveToken);
   clearTokenBtn.addEventListener('click', clearToken);
+  if (tokenAccountInput) {
+    tokenAccountInput.addEventListener('change', refreshTokenStatus);
+  }
+  if (connectNotionBtn) {
+    connectNotionBtn.addEventListener('click', connectNotion);
+    saveOAuthSecretBtn.addEventListener('click', saveOAuthSecret);
+  }
   addDatabaseBtn.addEventListener('click', addDatabase);
   if (openSettingsBtn) {
     openSettingsBtn.addEventListener('click', openSettingsWindow);
@@ -739,8 +1524,34 @@ function bindUI() {
   if (openBrainBtn) {
     openBrainBtn.addEventListener('click', openBrainWindow);
   }
+  if (profileSelect) {
+    switchProfileBtn.addEventListener('click', switchProfile);
+    exportProfileBtn.addEventListener('click', exportProfile);
+    addProfileBtn.addEventListener('click', addProfile);
+    importProfileBtn.addEventListener('click', () => importProfileInput.click());
+    importProfileInput.addEventListener('change', () => {
+      const file = importProfileInput.files?.[0];
+      if (file) {
+        importProfile(file);
+      }
+    });
+  }
+  if (addBrainProfileBtn) {
+    addBrainProfileBtn.addEventListener('click', addBrainProfile);
+  }
+  if (brainProfileSelect) {
+    brainProfileSelect.addEventListener('change', changeBrainProfile);
+  }
+  if (brainBodyInput && state.mode === 'brain') {
+    brainBodyInput.addEventListener('input', scheduleBrainDraft);
+    document.addEventListener('visibilitychange', () => {
+      if (document.hidden) {
+        flushBrainDraft();
+      }
+    });
+  }
   if (brainReloadBtn) {
-    brainReloadBtn.addEventListener('click', loadBrainTemplate);
+    brainReloadBtn.addEventListener('click', reloadBrainTemplate);
   }
   if (brainOpenTemplateBtn) {
     brainOpenTemplateBtn.addEventListener('click', openBrainTemplate);
@@ -757,6 +1568,9 @@ function bindUI() {
   if (brainSubmitBtn) {
     brainSubmitBtn.addEventListener('click', createBrainPage);
   }
+  if (brainDailyReviewBtn) {
+    brainDailyReviewBtn.addEventListener('click', createDailyReview);
+  }
 
   wails.Events.On('view-change', (event) => {
     const view = event?.data;
@@ -771,6 +1585,58 @@ function bindUI() {
   wails.Events.On('refresh', () => {
     refreshActiveView(true);
   });
+
+  // config.json が外部で編集され、アプリ側で読み込み直したとき
+  wails.Events.On('config-changed', async () => {
+    try {
+      await loadConfig();
+    } catch (err) {
+      setError(err.message);
+      return;
+    }
+    if (state.mode === 'main') {
+      setView(state.view);
+      refreshActiveView(true);
+      startPolling();
+    }
+  });
+
+  // トークンを拒否されて取得を止めたアカウントが変わったとき（空の一覧は回復）
+  wails.Events.On('token-invalid', (event) => {
+    const accounts = event?.data || [];
+    const added = accounts.filter((account) => !state.invalidTokenAccounts.includes(account));
+    state.invalidTokenAccounts = accounts;
+    setStatusChip();
+    if (added.length) {
+      const names = added.map((account) => account || '既定').join(', ');
+      setError(`Notion がトークン（${names}）を拒否したため、その DB の自動更新を止めました。設定でトークンを保存し直してください`);
+    }
+    if (state.mode === 'settings') {
+      refreshTokenStatus().catch((err) => setError(err.message));
+    }
+  });
+
+  wails.Events.On('focus', (event) => {
+    applyFocus(event?.data);
+  });
+  if (focusToggleBtn) {
+    focusToggleBtn.addEventListener('click', toggleFocus);
+  }
+  if (focusFinishBtn) {
+    focusFinishBtn.addEventListener('click', finishFocus);
+  }
+  if (focusCancelBtn) {
+    focusCancelBtn.addEventListener('click', cancelFocus);
+  }
+  if (timePeriodInput) {
+    timePeriodInput.addEventListener('change', loadTimeSummary);
+  }
+  if (exportTimeBtn) {
+    exportTimeBtn.addEventListener('click', exportTime);
+  }
+  if (syncTimeBtn) {
+    syncTimeBtn.addEventListener('click', syncTimeTotals);
+  }
 }
 
 async function init() {
@@ -785,9 +1651,15 @@ async function init() {
   setView(pickDefaultView());
   if (state.mode === 'brain') {
     await loadBrainTemplate();
+    await loadBrainNotes();
+    return;
+  }
+  if (state.mode === 'settings') {
+    await loadTimeSummary();
     return;
   }
   if (state.mode === 'main') {
+    applyFocus(await api.getFocus());
     refreshActiveView();
     startPolling();
   }
@@ -0,0 +1,562 @@
+// Code generated by rpcgen from internal/rpc/api; DO NOT EDIT.
+// @ts-check
+
+/**
+ * @typedef {Object} BrainDraft
+ * @property {string} profile
+ * @property {string} body
+ * @property {string} [updated_at]
+ */
+
+/**
+ * @typedef {Object} BrainDraftRequest
+ * @property {string} profile
+ */
+
+/**
+ * @typedef {Object} BrainNote
+ * @property {string} profile
+ * @property {string} excerpt
+ * @property {string} page_id
+ * @property {string} url
+ * @property {string} created_at
+ */
+
+/**
+ * @typedef {Object} BrainProfile
+ * @property {string} key
+ * @property {string} name
+ * @property {string} database_id
+ * @property {string} template_page_id
+ * @property {Object<string, string>} [properties]
+ * @property {string} title_prefix
+ * @property {boolean} title_date
+ * @property {string} [account]
+ */
+
+/**
+ * @typedef {Object} BrainTemplate
+ * @property {string} title
+ * @property {string} body
+ * @property {boolean} has_placeholder
+ */
+
+/**
+ * @typedef {Object} BrainTemplateRequest
+ * @property {string} profile
+ */
+
+/**
+ * @typedef {Object} Config
+ * @property {number} version
+ * @property {Array<DatabaseConfig>} databases
+ * @property {number} poll_interval_seconds
+ * @property {number} max_results
+ * @property {boolean} launch_at_login
+ * @property {string} tray_icon_path
+ * @property {string} tray_label_mode
+ * @property {number} tray_label_max_length
+ * @property {string} notion_version
+ * @property {Array<BrainProfile>} brain_profiles
+ * @property {NotificationConfig} notifications
+ * @property {DueReminderConfig} due_reminders
+ * @property {number} focus_minutes
+ * @property {DailyReviewConfig} daily_review
+ * @property {OAuthConfig} oauth
+ * @property {Array<ConfigProfile>} [profiles]
+ * @property {string} [active_profile]
+ * @property {string} [revision]
+ */
+
+/**
+ * @typedef {Object} ConfigProfile
+ * @property {string} name
+ * @property {string} [token_account]
+ * @property {Array<DatabaseConfig>} databases
+ */
+
+/**
+ * @typedef {Object} CreateBrainPageRequest
+ * @property {string} profile
+ * @property {string} body
+ */
+
+/**
+ * @typedef {Object} CreatedPage
+ * @property {string} id
+ * @property {string} url
+ */
+
+/**
+ * @typedef {Object} DailyReviewConfig
+ * @property {boolean} enabled
+ * @property {string} time
+ * @property {string} title
+ * @property {string} profile
+ */
+
+/**
+ * @typedef {Object} DatabaseConfig
+ * @property {string} key
+ * @property {string} name
+ * @property {string} kind
+ * @property {boolean} enabled
+ * @property {string} database_id
+ * @property {string} data_source_id
+ * @property {string} title_property_name
+ * @property {string} status_property_name
+ * @property {string} status_property_type
+ * @property {string} status_in_progress
+ * @property {string} status_done
+ * @property {string} status_paused
+ * @property {string} checkbox_property_name
+ * @property {string} due_property_name
+ * @property {string} time_property_name
+ * @property {string} assignee_property_name
+ * @property {string} time_total_property_name
+ * @property {string} [account]
+ */
+
+/**
+ * @typedef {Object} DatabaseTime
+ * @property {string} key
+ * @property {string} name
+ * @property {number} seconds
+ * @property {Array<TaskTime>} tasks
+ */
+
+/**
+ * @typedef {Object} DueReminderConfig
+ * @property {boolean} at_due
+ * @property {number} lead_minutes
+ * @property {boolean} morning_digest
+ * @property {string} digest_time
+ */
+
+/**
+ * @typedef {Object} ExportTimeCSVRequest
+ * @property {string} from
+ * @property {string} to
+ */
+
+/**
+ * @typedef {Object} FieldError
+ * @property {string} path
+ * @property {string} message
+ */
+
+/**
+ * @typedef {Object} FocusSession
+ * @property {string} database_key
+ * @property {string} task_id
+ * @property {string} task_title
+ * @property {string} task_url
+ * @property {string} started_at
+ * @property {number} duration_seconds
+ * @property {number} elapsed_seconds
+ * @property {string} resumed_at
+ * @property {boolean} paused
+ * @property {number} remaining_seconds
+ */
+
+/**
+ * @typedef {Object} GetHabitsRequest
+ * @property {string} database_key
+ * @property {boolean} force_refresh
+ */
+
+/**
+ * @typedef {Object} GetTasksRequest
+ * @property {string} database_key
+ * @property {boolean} force_refresh
+ */
+
+/**
+ * @typedef {Object} NotificationConfig
+ * @property {boolean} task_started
+ * @property {boolean} task_edited
+ * @property {boolean} task_assigned
+ * @property {boolean} habit_reminder
+ * @property {Array<string>} habit_reminder_times
+ * @property {boolean} sync_failure
+ * @property {number} sync_failure_threshold
+ * @property {string} notion_user_id
+ */
+
+/**
+ * @typedef {Object} NotionWorkspace
+ * @property {string} [id]
+ * @property {string} name
+ * @property {string} [icon]
+ * @property {string} [bot_id]
+ */
+
+/**
+ * @typedef {Object} OAuthClientSecretRequest
+ * @property {string} secret
+ */
+
+/**
+ * @typedef {Object} OAuthConfig
+ * @property {string} client_id
+ * @property {string} authorize_url
+ * @property {string} token_url
+ * @property {number} redirect_port
+ */
+
+/**
+ * @typedef {Object} OpenURLRequest
+ * @property {string} url
+ */
+
+/**
+ * @typedef {Object} ProfileBundle
+ * @property {string} format
+ * @property {number} version
+ * @property {string} name
+ * @property {string} [notion_version]
+ * @property {Array<DatabaseConfig>} databases
+ */
+
+/**
+ * @typedef {Object} ProfileRequest
+ * @property {string} name
+ */
+
+/**
+ * @typedef {Object} ResolveRequest
+ * @property {string} database_id
+ * @property {string} [account]
+ */
+
+/**
+ * @typedef {Object} SaveBrainDraftRequest
+ * @property {string} profile
+ * @property {string} body
+ */
+
+/**
+ * @typedef {Object} SetTokenRequest
+ * @property {string} token
+ * @property {string} [account]
+ */
+
+/**
+ * @typedef {Object} StartFocusRequest
+ * @property {string} database_key
+ * @property {string} task_id
+ */
+
+/**
+ * @typedef {Object} Task
+ * @property {string} id
+ * @property {string} title
+ * @property {string} url
+ * @property {string} status
+ * @property {string} last_edited_time
+ * @property {string} [last_edited_by]
+ * @property {boolean} checked
+ * @property {string} [due]
+ * @property {boolean} overdue
+ * @property {Array<string>} [assignees]
+ */
+
+/**
+ * @typedef {Object} TaskTime
+ * @property {string} page_id
+ * @property {string} title
+ * @property {number} seconds
+ */
+
+/**
+ * @typedef {Object} TimeSummary
+ * @property {string} from
+ * @property {string} to
+ * @property {number} total_seconds
+ * @property {Array<DatabaseTime>} databases
+ */
+
+/**
+ * @typedef {Object} TimeSummaryRequest
+ * @property {string} period
+ * @property {string} date
+ */
+
+/**
+ * @typedef {Object} TokenAccountRequest
+ * @property {string} [account]
+ */
+
+/**
+ * @typedef {Object} TokenIdentity
+ * @property {string} id
+ * @property {string} name
+ * @property {string} [workspace_name]
+ */
+
+/**
+ * @typedef {Object} UpdateHabitCheckRequest
+ * @property {string} database_key
+ * @property {string} task_id
+ * @property {boolean} checked
+ */
+
+/**
+ * @typedef {Object} UpdateStatusRequest
+ * @property {string} database_key
+ * @property {string} task_id
+ * @property {string} action
+ */
+
+/**
+ * RPC 呼び出し関数（action, payload）を受け取り、アクションごとの型付きメソッドを返す。
+ * @param {(action: string, payload?: any) => Promise<any>} call
+ */
+export function createClient(call) {
+  return {
+    /**
+     * 設定ファイルを読み込み直して返す
+     * @returns {Promise<Config>}
+     */
+    getConfig: () => call('getConfig'),
+    /**
+     * 設定を検証して保存しポーリングを再開し、保存後の revision を返す。読み込み後にファイルが変わっていれば config_conflict
+     * @param {Config} payload
+     * @returns {Promise<string>}
+     */
+    saveConfig: (payload) => call('saveConfig', payload),
+    /**
+     * 設定を保存せずに検証し、誤りのある項目を返す
+     * @param {Config} payload
+     * @returns {Promise<Array<FieldError>>}
+     */
+    validateConfig: (payload) => call('validateConfig', payload),
+    /**
+     * アカウントのトークンが保存済みかを返す
+     * @param {TokenAccountRequest} payload
+     * @returns {Promise<boolean>}
+     */
+    getTokenStatus: (payload) => call('getTokenStatus', payload),
+    /**
+     * トークンを Notion に確認してアカウントに保存し、インテグレーションの情報を返す
+     * @param {SetTokenRequest} payload
+     * @returns {Promise<TokenIdentity>}
+     */
+    setToken: (payload) => call('setToken', payload),
+    /**
+     * 保存済みのトークンを Notion に確認し、インテグレーション名とワークスペースを返す
+     * @param {TokenAccountRequest} payload
+     * @returns {Promise<TokenIdentity>}
+     */
+    verifyToken: (payload) => call('verifyToken', payload),
+    /**
+     * OAuth でブラウザから Notion と接続し、アクセストークンをアカウントに保存する
+     * @param {TokenAccountRequest} payload
+     * @returns {Promise<NotionWorkspace>}
+     */
+    connectNotion: (payload) => call('connectNotion', payload),
+    /**
+     * OAuth のクライアントシークレットを保存する（空なら削除）
+     * @param {OAuthClientSecretRequest} payload
+     * @returns {Promise<void>}
+     */
+    setOAuthClientSecret: (payload) => call('setOAuthClientSecret', payload),
+    /**
+     * アカウントのトークンを削除する
+     * @param {TokenAccountRequest} payload
+     * @returns {Promise<void>}
+     */
+    clearToken: (payload) => call('clearToken', payload),
+    /**
+     * Database ID から Data Source ID を解決する
+     * @param {ResolveRequest} payload
+     * @returns {Promise<string>}
+     */
+    resolveDataSourceID: (payload) => call('resolveDataSourceID', payload),
+    /**
+     * Database のタイトルプロパティ名を解決する
+     * @param {ResolveRequest} payload
+     * @returns {Promise<string>}
+     */
+    resolveTitlePropertyName: (payload) => call('resolveTitlePropertyName', payload),
+    /**
+     * Brain プロファイルのテンプレートを取得する
+     * @param {BrainTemplateRequest} payload
+     * @returns {Promise<BrainTemplate>}
+     */
+    getBrainTemplate: (payload) => call('getBrainTemplate', payload),
+    /**
+     * Brain プロファイルの DB にページを作成する
+     * @param {CreateBrainPageRequest} payload
+     * @returns {Promise<CreatedPage>}
+     */
+    createBrainPage: (payload) => call('createBrainPage', payload),
+    /**
+     * Brain プロファイルの未登録の下書きを返す
+     * @param {BrainDraftRequest} payload
+     * @returns {Promise<BrainDraft>}
+     */
+    getBrainDraft: (payload) => call('getBrainDraft', payload),
+    /**
+     * Brain の下書きを更新する（ファイルへの書き込みは少し遅れる）
+     * @param {SaveBrainDraftRequest} payload
+     * @returns {Promise<void>}
+     */
+    saveBrainDraft: (payload) => call('saveBrainDraft', payload),
+    /**
+     * Brain から登録したメモの履歴を新しい順に返す
+     * @returns {Promise<Array<BrainNote>>}
+     */
+    getBrainNotes: () => call('getBrainNotes'),
+    /**
+     * 今日のまとめを Brain にページとして作成する
+     * @returns {Promise<CreatedPage>}
+     */
+    createDailyReview: () => call('createDailyReview'),
+    /**
+     * 進行中タスクを返す（キャッシュ優先）
+     * @param {GetTasksRequest} payload
+     * @returns {Promise<Array<Task>>}
+     */
+    getTasks: (payload) => call('getTasks', payload),
+    /**
+     * 今日の未チェック習慣を返す（キャッシュ優先）
+     * @param {GetHabitsRequest} payload
+     * @returns {Promise<Array<Task>>}
+     */
+    getHabits: (payload) => call('getHabits', payload),
+    /**
+     * タスクのステータスを更新する
+     * @param {UpdateStatusRequest} payload
+     * @returns {Promise<void>}
+     */
+    updateStatus: (payload) => call('updateStatus', payload),
+    /**
+     * 今日の習慣チェックを更新する
+     * @param {UpdateHabitCheckRequest} payload
+     * @returns {Promise<void>}
+     */
+    updateHabitCheck: (payload) => call('updateHabitCheck', payload),
+    /**
+     * 実行中の集中セッションを返す（なければ null）
+     * @returns {Promise<FocusSession|null>}
+     */
+    getFocus: () => call('getFocus'),
+    /**
+     * タスクの集中セッションを開始する
+     * @param {StartFocusRequest} payload
+     * @returns {Promise<FocusSession>}
+     */
+    startFocus: (payload) => call('startFocus', payload),
+    /**
+     * 集中セッションを一時停止する
+     * @returns {Promise<FocusSession>}
+     */
+    pauseFocus: () => call('pauseFocus'),
+    /**
+     * 集中セッションを再開する
+     * @returns {Promise<FocusSession>}
+     */
+    resumeFocus: () => call('resumeFocus'),
+    /**
+     * 集中セッションを終了し経過時間を記録する
+     * @returns {Promise<void>}
+     */
+    finishFocus: () => call('finishFocus'),
+    /**
+     * 集中セッションを記録せずに破棄する
+     * @returns {Promise<void>}
+     */
+    cancelFocus: () => call('cancelFocus'),
+    /**
+     * 日/週の作業時間を DB・タスクごとに集計する
+     * @param {TimeSummaryRequest} payload
+     * @returns {Promise<TimeSummary>}
+     */
+    getTimeSummary: (payload) => call('getTimeSummary', payload),
+    /**
+     * 期間の作業時間を CSV に書き出し、保存先のパスを返す
+     * @param {ExportTimeCSVRequest} payload
+     * @returns {Promise<string>}
+     */
+    exportTimeCSV: (payload) => call('exportTimeCSV', payload),
+    /**
+     * 累計作業時間を Notion の数値プロパティへ書き込み、更新件数を返す
+     * @returns {Promise<number>}
+     */
+    syncTimeTotals: () => call('syncTimeTotals'),
+    /**
+     * 設定プロファイルを切り替えてポーリングをやり直す
+     * @param {ProfileRequest} payload
+     * @returns {Promise<void>}
+     */
+    switchProfile: (payload) => call('switchProfile', payload),
+    /**
+     * プロファイルをトークンを含まない JSON に書き出し、保存先のパスを返す
+     * @param {ProfileRequest} payload
+     * @returns {Promise<string>}
+     */
+    exportProfile: (payload) => call('exportProfile', payload),
+    /**
+     * 書き出したプロファイルを取り込み、プロファイル名を返す
+     * @param {ProfileBundle} payload
+     * @returns {Promise<string>}
+     */
+    importProfile: (payload) => call('importProfile', payload),
+    /**
+     * 既定のブラウザで URL を開く
+     * @param {OpenURLRequest} payload
+     * @returns {Promise<void>}
+     */
+    openURL: (payload) => call('openURL', payload),
+    /**
+     * 設定ウィンドウを表示する
+     * @returns {Promise<void>}
+     */
+    openSettingsWindow: () => call('openSettingsWindow'),
+    /**
+     * Brain ウィンドウを表示する
+     * @returns {Promise<void>}
+     */
+    openBrainWindow: () => call('openBrainWindow'),
+  };
+}
+
+export const actions = [
+  'getConfig',
+  'saveConfig',
+  'validateConfig',
+  'getTokenStatus',
+  'setToken',
+  'verifyToken',
+  'connectNotion',
+  'setOAuthClientSecret',
+  'clearToken',
+  'resolveDataSourceID',
+  'resolveTitlePropertyName',
+  'getBrainTemplate',
+  'createBrainPage',
+  'getBrainDraft',
+  'saveBrainDraft',
+  'getBrainNotes',
+  'createDailyReview',
+  'getTasks',
+  'getHabits',
+  'updateStatus',
+  'updateHabitCheck',
+  'getFocus',
+  'startFocus',
+  'pauseFocus',
+  'resumeFocus',
+  'finishFocus',
+  'cancelFocus',
+  'getTimeSummary',
+  'exportTimeCSV',
+  'syncTimeTotals',
+  'switchProfile',
+  'exportProfile',
+  'importProfile',
+  'openURL',
+  'openSettingsWindow',
+  'openBrainWindow',
+];
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+
+	coreapp "nudge/internal/app"
+	"nudge/internal/ipc"
+)
+
+// runSend はクライアントモード（`nudge --send <command> [args...]`）の処理。
+func runSend(socketPath string, args []string) int {
+	req, err := ipc.ParseArgs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nudge:", err)
+		return 2
+	}
+	resp, err := ipc.Send(socketPath, req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nudge:", err)
+		return 1
+	}
+	if resp.Data != nil {
+		b, err := json.Marshal(resp.Data)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "nudge:", err)
+			return 1
+		}
+		fmt.Println(string(b))
+	}
+	return 0
+}
+
+// forwardToRunningInstance は二重起動時に引数を先行プロセスへ渡す。
+// コマンドとして解釈できない引数（macOS が付ける -psn_* など）は捨て、ポップオーバーの表示として渡す。
+func forwardToRunningInstance(socketPath string, args []string) error {
+	if _, err := ipc.ParseArgs(args); err != nil {
+		log.Printf("ignore forwarded arguments %q: %v", args, err)
+		args = nil
+	}
+	_, err := ipc.Send(socketPath, ipc.Request{Command: ipc.CommandForward, Args: args})
+	return err
+}
+
+func newControlHandler(core *coreapp.App, popover *application.WebviewWindow, brainWindow *application.WebviewWindow) ipc.Handler {
+	var handle ipc.Handler
+	handle = func(ctx context.Context, req ipc.Request) (any, error) {
+		switch req.Command {
+		case ipc.CommandForward:
+			forwarded, err := ipc.ParseArgs(req.Args)
+			if err != nil {
+				return nil, err
+			}
+			return handle(ctx, forwarded)
+		case ipc.CommandRefresh:
+			if err := core.Refresh(ctx); err != nil {
+				return nil, err
+			}
+			popover.EmitEvent("refresh")
+			return core.Counts(), nil
+		case ipc.CommandShowPopover:
+			popover.Show()
+			popover.Focus()
+			return nil, nil
+		case ipc.CommandOpenBrainWindow:
+			showBrainWindow(brainWindow)
+			return nil, nil
+		case ipc.CommandAddBrainNote:
+			return core.CreateBrainPage(ctx, "", req.Body)
+		case ipc.CommandGetCounts:
+			return core.Counts(), nil
+		default:
+			return nil, fmt.Errorf("unknown command: %s", req.Command)
+		}
+	}
+	return handle
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	coreapp "nudge/internal/app"
+	"nudge/internal/mcp"
+)
+
+// runMCP は `nudge mcp` モード（stdio 上の MCP サーバ）を実行する。
+// トレイやポーリングは起動せず、設定と Keychain のトークンだけを共有する。
+func runMCP(core *coreapp.App) int {
+	if _, err := core.LoadConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, "nudge mcp: load config:", err)
+		return 1
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	server := mcp.NewServer(core, coreapp.AppName, coreapp.Version)
+	if err := server.Serve(ctx, os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+		fmt.Fprintln(os.Stderr, "nudge mcp:", err)
+		return 1
+	}
+	return 0
+}
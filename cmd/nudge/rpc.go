@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+	"github.com/wailsapp/wails/v3/pkg/events"
+
+	coreapp "nudge/internal/app"
+	"nudge/internal/dto"
+	"nudge/internal/notion"
+	"nudge/internal/rpc"
+	"nudge/internal/rpc/api"
+	"nudge/internal/store"
+)
+
+const (
+	// codeTokenMissing は Notion トークン未設定を表す RPC エラーコード
+	codeTokenMissing = "token_missing"
+	// codeTokenInvalid は Notion がトークンを拒否した（401）ことを表す RPC エラーコード
+	codeTokenInvalid = "token_invalid"
+	// codeConfigConflict は設定ファイルが外部で書き換えられていて保存しなかったことを表す RPC エラーコード
+	codeConfigConflict = "config_conflict"
+	// codeInvalidConfig は設定の検証エラーを表す RPC エラーコード（メッセージは 1 行 1 項目）
+	codeInvalidConfig = "invalid_config"
+
+	rpcTimeout      = 20 * time.Second
+	rpcLongTimeout  = 60 * time.Second
+	rpcOAuthTimeout = 5 * time.Minute // ブラウザでの Notion の認可を待つ時間
+	rpcEventName    = "rpc:response"
+	wailsOrigin     = "wails://"
+	wailsHTTPOrigin = "http://wails.localhost"
+)
+
+// newRouter は RPC アクションを登録したルーターを組み立てる。
+func newRouter(core *coreapp.App, app *application.App, settingsWindow *application.WebviewWindow, brainWindow *application.WebviewWindow) *rpc.Router {
+	logger := slog.Default()
+	r := rpc.NewRouter()
+	r.Use(
+		rpc.Recover(logger),
+		rpc.Logging(logger),
+		rpc.TrustedOrigin(wailsOrigin, wailsHTTPOrigin),
+		rpc.ErrorCodes(mapErrorCode),
+		// テンプレート取得・ページ作成は複数 API 呼び出しになるため長めに取る
+		rpc.Timeout(rpcTimeout, map[string]time.Duration{
+			api.GetBrainTemplate.Name:  rpcLongTimeout,
+			api.CreateBrainPage.Name:   rpcLongTimeout,
+			api.CreateDailyReview.Name: rpcLongTimeout,
+			api.FinishFocus.Name:       rpcLongTimeout,
+			api.SyncTimeTotals.Name:    rpcLongTimeout,
+			api.ConnectNotion.Name:     rpcOAuthTimeout,
+		}),
+	)
+
+	rpc.Register(r, api.GetConfig, func(ctx context.Context, _ rpc.Empty) (dto.Config, error) {
+		return core.LoadConfig()
+	})
+	rpc.Register(r, api.SaveConfig, func(ctx context.Context, cfg dto.Config) (string, error) {
+		revision, err := core.SaveConfig(cfg)
+		if err != nil {
+			return "", err
+		}
+		core.StartBackgroundPolling()
+		return revision, nil
+	})
+	rpc.Register(r, api.ValidateConfig, func(ctx context.Context, cfg dto.Config) ([]dto.FieldError, error) {
+		return core.ValidateConfig(cfg), nil
+	})
+	rpc.Register(r, api.GetTokenStatus, func(ctx context.Context, req api.TokenAccountRequest) (bool, error) {
+		token, err := core.GetToken(req.Account)
+		if err != nil {
+			if errors.Is(err, store.ErrTokenNotFound) {
+				return false, nil
+			}
+			return false, err
+		}
+		return token != "", nil
+	})
+	rpc.Register(r, api.SetToken, func(ctx context.Context, req api.SetTokenRequest) (dto.TokenIdentity, error) {
+		return core.SetToken(ctx, req.Account, req.Token)
+	})
+	rpc.Register(r, api.ConnectNotion, func(ctx context.Context, req api.TokenAccountRequest) (dto.NotionWorkspace, error) {
+		return core.ConnectNotion(ctx, req.Account, app.Browser.OpenURL)
+	})
+	rpc.Register(r, api.SetOAuthClientSecret, func(ctx context.Context, req api.OAuthClientSecretRequest) (rpc.Empty, error) {
+		return rpc.Empty{}, core.SetOAuthClientSecret(req.Secret)
+	})
+	rpc.Register(r, api.VerifyToken, func(ctx context.Context, req api.TokenAccountRequest) (dto.TokenIdentity, error) {
+		return core.VerifyToken(ctx, req.Account)
+	})
+	rpc.Register(r, api.ClearToken, func(ctx context.Context, req api.TokenAccountRequest) (rpc.Empty, error) {
+		return rpc.Empty{}, core.ClearToken(req.Account)
+	})
+	rpc.Register(r, api.ResolveDataSourceID, func(ctx context.Context, req api.ResolveRequest) (string, error) {
+		return core.ResolveDataSourceID(ctx, req.DatabaseID, req.Account)
+	})
+	rpc.Register(r, api.ResolveTitlePropertyName, func(ctx context.Context, req api.ResolveRequest) (string, error) {
+		return core.ResolveTitlePropertyName(ctx, req.DatabaseID, req.Account)
+	})
+	rpc.Register(r, api.GetBrainTemplate, func(ctx context.Context, req api.BrainTemplateRequest) (dto.BrainTemplate, error) {
+		return core.GetBrainTemplate(ctx, req.Profile)
+	})
+	rpc.Register(r, api.CreateBrainPage, func(ctx context.Context, req api.CreateBrainPageRequest) (dto.CreatedPage, error) {
+		return core.CreateBrainPage(ctx, req.Profile, req.Body)
+	})
+	rpc.Register(r, api.GetBrainDraft, func(ctx context.Context, req api.BrainDraftRequest) (dto.BrainDraft, error) {
+		return core.BrainDraft(req.Profile), nil
+	})
+	rpc.Register(r, api.SaveBrainDraft, func(ctx context.Context, req api.SaveBrainDraftRequest) (rpc.Empty, error) {
+		core.SaveBrainDraft(req.Profile, req.Body)
+		return rpc.Empty{}, nil
+	})
+	rpc.Register(r, api.GetBrainNotes, func(ctx context.Context, _ rpc.Empty) ([]dto.BrainNote, error) {
+		return core.BrainNotes()
+	})
+	rpc.Register(r, api.CreateDailyReview, func(ctx context.Context, _ rpc.Empty) (dto.CreatedPage, error) {
+		return core.CreateDailyReview(ctx)
+	})
+	rpc.Register(r, api.GetTasks, func(ctx context.Context, req api.GetTasksRequest) ([]dto.Task, error) {
+		return core.GetTasks(ctx, req.DatabaseKey, req.ForceRefresh)
+	})
+	rpc.Register(r, api.GetHabits, func(ctx context.Context, req api.GetHabitsRequest) ([]dto.Task, error) {
+		return core.GetHabits(ctx, req.DatabaseKey, req.ForceRefresh)
+	})
+	rpc.Register(r, api.UpdateStatus, func(ctx context.Context, req api.UpdateStatusRequest) (rpc.Empty, error) {
+		return rpc.Empty{}, core.UpdateTaskStatus(ctx, req.DatabaseKey, req.TaskID, req.Action)
+	})
+	rpc.Register(r, api.UpdateHabitCheck, func(ctx context.Context, req api.UpdateHabitCheckRequest) (rpc.Empty, error) {
+		return rpc.Empty{}, core.UpdateHabitCheck(ctx, req.DatabaseKey, req.TaskID, req.Checked)
+	})
+	rpc.Register(r, api.GetFocus, func(ctx context.Context, _ rpc.Empty) (*dto.FocusSession, error) {
+		return core.CurrentFocus(), nil
+	})
+	rpc.Register(r, api.StartFocus, func(ctx context.Context, req api.StartFocusRequest) (dto.FocusSession, error) {
+		return core.StartFocus(req.DatabaseKey, req.TaskID)
+	})
+	rpc.Register(r, api.PauseFocus, func(ctx context.Context, _ rpc.Empty) (dto.FocusSession, error) {
+		return core.PauseFocus()
+	})
+	rpc.Register(r, api.ResumeFocus, func(ctx context.Context, _ rpc.Empty) (dto.FocusSession, error) {
+		return core.ResumeFocus()
+	})
+	rpc.Register(r, api.FinishFocus, func(ctx context.Context, _ rpc.Empty) (rpc.Empty, error) {
+		return rpc.Empty{}, core.FinishFocus(ctx)
+	})
+	rpc.Register(r, api.CancelFocus, func(ctx context.Context, _ rpc.Empty) (rpc.Empty, error) {
+		return rpc.Empty{}, core.CancelFocus()
+	})
+	rpc.Register(r, api.GetTimeSummary, func(ctx context.Context, req api.TimeSummaryRequest) (dto.TimeSummary, error) {
+		date := time.Now()
+		if req.Date != "" {
+			parsed, err := parseDate(req.Date)
+			if err != nil {
+				return dto.TimeSummary{}, err
+			}
+			date = parsed
+		}
+		return core.TimeSummary(req.Period, date)
+	})
+	rpc.Register(r, api.ExportTimeCSV, func(ctx context.Context, req api.ExportTimeCSVRequest) (string, error) {
+		from, err := parseDate(req.From)
+		if err != nil {
+			return "", err
+		}
+		to, err := parseDate(req.To)
+		if err != nil {
+			return "", err
+		}
+		if to.Before(from) {
+			return "", rpc.Errorf(rpc.CodeInvalidPayload, "to is before from")
+		}
+		return core.WriteTimeCSV(from, to.AddDate(0, 0, 1))
+	})
+	rpc.Register(r, api.SyncTimeTotals, func(ctx context.Context, _ rpc.Empty) (int, error) {
+		return core.SyncTimeTotals(ctx)
+	})
+	rpc.Register(r, api.SwitchProfile, func(ctx context.Context, req api.ProfileRequest) (rpc.Empty, error) {
+		return rpc.Empty{}, core.SwitchProfile(req.Name)
+	})
+	rpc.Register(r, api.ExportProfile, func(ctx context.Context, req api.ProfileRequest) (string, error) {
+		return core.WriteProfileBundle(req.Name)
+	})
+	rpc.Register(r, api.ImportProfile, func(ctx context.Context, bundle dto.ProfileBundle) (string, error) {
+		return core.ImportProfile(bundle)
+	})
+	rpc.Register(r, api.OpenURL, func(ctx context.Context, req api.OpenURLRequest) (rpc.Empty, error) {
+		if req.URL == "" {
+			return rpc.Empty{}, rpc.Errorf(rpc.CodeInvalidPayload, "url is empty")
+		}
+		return rpc.Empty{}, app.Browser.OpenURL(req.URL)
+	})
+	rpc.Register(r, api.OpenSettingsWindow, func(ctx context.Context, _ rpc.Empty) (rpc.Empty, error) {
+		if settingsWindow == nil {
+			return rpc.Empty{}, fmt.Errorf("settings window unavailable")
+		}
+		showSettingsWindow(settingsWindow)
+		return rpc.Empty{}, nil
+	})
+	rpc.Register(r, api.OpenBrainWindow, func(ctx context.Context, _ rpc.Empty) (rpc.Empty, error) {
+		if brainWindow == nil {
+			return rpc.Empty{}, fmt.Errorf("brain window unavailable")
+		}
+		showBrainWindow(brainWindow)
+		return rpc.Empty{}, nil
+	})
+	return r
+}
+
+// parseDate は YYYY-MM-DD をローカル時刻の 0 時として解釈する。
+func parseDate(value string) (time.Time, error) {
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, rpc.Errorf(rpc.CodeInvalidPayload, "invalid date: %s", value)
+	}
+	return t, nil
+}
+
+func mapErrorCode(err error) string {
+	if errors.Is(err, store.ErrTokenNotFound) || errors.Is(err, notion.ErrTokenNotSet) {
+		return codeTokenMissing
+	}
+	if errors.Is(err, notion.ErrUnauthorized) {
+		return codeTokenInvalid
+	}
+	if errors.Is(err, store.ErrConfigConflict) {
+		return codeConfigConflict
+	}
+	var invalid dto.ValidationErrors
+	if errors.As(err, &invalid) {
+		return codeInvalidConfig
+	}
+	return ""
+}
+
+// cancelOnHide はウィンドウが隠れたときに処理中の読み取りの RPC（Endpoint.CancelOnHide）をキャンセルする。
+// ページの作成やステータスの更新などは隠れても最後まで実行する。
+func cancelOnHide(router *rpc.Router, windows ...*application.WebviewWindow) {
+	for _, w := range windows {
+		name := w.Name()
+		w.OnWindowEvent(events.Common.WindowHide, func(event *application.WindowEvent) {
+			router.CancelWindow(name)
+		})
+	}
+}
+
+// handleRawMessage は JS からのメッセージをルーターに渡し、結果をイベントで返す。
+func handleRawMessage(router *rpc.Router, window application.Window, message string, origin *application.OriginInfo) {
+	var req rpc.Request
+	if err := json.Unmarshal([]byte(message), &req); err != nil {
+		return
+	}
+	call := &rpc.Call{Request: req, Window: window.Name()}
+	if origin != nil {
+		call.Origin = &rpc.Origin{Origin: origin.Origin, IsMainFrame: origin.IsMainFrame}
+	}
+	// 処理中にウィンドウ非表示などでキャンセルできるよう、メッセージ処理とは切り離して実行する
+	go func() {
+		resp := router.Dispatch(context.Background(), call)
+		// 外部起点のメッセージには返信しない
+		if resp.Code == rpc.CodeForbidden {
+			return
+		}
+		window.EmitEvent(rpcEventName, resp)
+	}()
+}
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+
+	coreapp "nudge/internal/app"
+	"nudge/internal/dto"
+	"nudge/internal/tray"
+)
+
+const (
+	// メニューバー（SystemTray）用のフォールバックアイコン（PNG を base64 化）
+	// tray_icon_path が未設定・読み込み失敗時に使う
+	trayIconBase64 = "iVBORw0KGgoAAAANSUhEUgAAACAAAAAgCAYAAABzenr0AAAAAXNSR0IArs4c6QAAAHhlWElmTU0AKgAAAAgABAEaAAUAAAABAAAAPgEbAAUAAAABAAAARgEoAAMAAAABAAIAAIdpAAQAAAABAAAATgAAAAAAAABIAAAAAQAAAEgAAAABAAOgAQADAAAAAQABAACgAgAEAAAAAQAAACCgAwAEAAAAAQAAACAAAAAAnwlWxAAAAAlwSFlzAAALEwAACxMBAJqcGAAABDJJREFUWAnFV10oZWsYXtv/X9JgojONGadjOqcjbpQLuXRx4shMcaFRhPx1dC5QJEQJSeFCEunkYm4oTXLhghElZUZycVzoKCRi/J2z/e73PO871teasda2ttrNW+9e3/e8f8/6/ta3Ne07i8NdfSLyhd2tj5t4urORw+FwWfndS46i4XB+C/0N+gPUB/oYUQQQvA+dho6AzGfLZCj+K/Qj1FuyhsSJpgRgeAr921uVDXm5xlOdhHF4/wSYoBu8+OQaf+j5hQAYBQF4rYNWz+3tbW1zc9PK7An+BjUDVQA6z6FnUFO5vb2lhoYGCg4OJl9fXyosLKSrqytTX5vgv/B7YSTwI4D/rIJnZmZ4RVNzczP19/fztqLy8nIrdzs4s//FNgEUdfn7+9Px8bEkHxoaEkJtbW12ipn5eEZgbW2NAgMDqaSkRCVramoSEiMjIwrzoPEwgevraxobG6OdnR3JOzg4KAVbW1ul73K5qKioSNbE9PS0B7XF9RK/P7udgtPTU4qNjaXU1FQ6OTmRqMbGRiExOjoqfafTSRkZGRQeHk4rKyuCGX92d3eJ1UQeJsBB8/PzFBQURLm5uXRzc0P6WwcEBNDCwoLkPTg4oMTERIqPj6f9/X3BDg8PKT8/X2J56jie/QxijwAHDA8Py1tPTU1JPL91UlISpaenE29PlvX1deJF2tHRIf2CggIp3tnZST09PRQWFkZ5eXnyAuJAZJ8AF4yOjqaampq7WKLu7m4KCQkhflNd0tLSKDMzk87Pz9nmamlp0U1MwsWjtre3p2OKgPEoVmvi2waiND8/PwVjOqSPQ0lhGA0NRTQfHx8NuOPi4kLZ0HYwzmoqKPAT1KnTMz77+vpkCmZnZwXmsyAhIYGysrKU29LSEiE5DQwMCFZVVSX92tpaqq+vl+kpLi5W/mioERBCAEwJTE5OyqlXWloq88fHb05ODoWGhtLq6qok3Nraori4OEpOTibeOSw8DdXV1TJ1UVFRVFlZqWzi8IXAKzUaAO8R4GQ897zNeB2w8JshiCYmJqTPo5GSkkIxMTG0sbEhmPHn7Ozs28K6mUfAPQFe4fwNODo6kiBe4VycvwUsPBrZ2dnygVpcXBTMg5+HCRiTLS8vy4lXV1en4IqKCiE0Pj6uMA8anhHo7e2VbaTPcXt7uxTnBfpI8YzA3NycFCwrK5NVzVNhHI1HkLhH4BmSfFnCJtn4CO7q6iJe0Xzu86Gkn4Im7nYgvpC85F0g13J0/NFehiYxaCVY9RofOJGRkVYudvENOCbjYuOU4w2Na5D4C6BbAhEREXYLPOT3jot/5QQCodAPUG/LJxR48lVxvQNDDPS9FxnweS5zr9c0+2vGX4zfoXxN5zs8X595F1iJ7BALI9tuoP9AJ6HjGPpLPJXcI6Asdw0wtviEKU935DQUdGtXWb5X43+lFriav9FvawAAAABJRU5ErkJggg=="
+)
+
+const (
+	trayActionTimeout  = 20 * time.Second
+	trayTitleMaxLength = 32
+)
+
+// trayController はバックグラウンド更新の結果をメニューバーのラベル/アイコン/メニューに反映する。
+type trayController struct {
+	app            *application.App
+	window         *application.WebviewWindow
+	settingsWindow *application.WebviewWindow
+	systray        *application.SystemTray
+	menu           *application.Menu
+	icons          tray.Icons
+	core           *coreapp.App
+
+	// menuMu はメニューの作り直しを直列にする。状態と集中セッションのリスナーは別の goroutine から呼ばれる
+	menuMu sync.Mutex
+
+	mu        sync.Mutex
+	iconState tray.IconState
+	snapshot  []dto.DatabaseSnapshot
+	focus     *dto.FocusSession
+	focusStop chan struct{}
+}
+
+func setupTray(app *application.App, window *application.WebviewWindow, settingsWindow *application.WebviewWindow, core *coreapp.App) *trayController {
+	// パス指定があれば PNG を直接読み込む（base64 変換不要）
+	icon := loadTrayIcon(core.GetConfig())
+	icons, err := tray.NewIcons(icon)
+	if err != nil {
+		log.Printf("tray icon: %v", err)
+	}
+	// メニューバー（SystemTray）を構成
+	systray := app.SystemTray.New()
+	if icon != nil {
+		systray.SetIcon(icon)
+		systray.SetDarkModeIcon(icon)
+	}
+	// 初回の更新結果が届くまではアイコンのみ表示
+	systray.SetLabel("")
+
+	t := &trayController{
+		app:            app,
+		window:         window,
+		settingsWindow: settingsWindow,
+		systray:        systray,
+		menu:           app.NewMenu(),
+		icons:          icons,
+		core:           core,
+	}
+	t.buildMenu(nil)
+	// メニュー適用とウィンドウの紐付け
+	systray.SetMenu(t.menu)
+	systray.AttachWindow(window)
+
+	core.AddStateListener(t.apply)
+	core.AddFocusListener(t.applyFocus)
+	// プロファイルの切り替えやステータス名の変更はキャッシュが変わらなくてもメニューに出るため、作り直す
+	core.AddConfigListener(func(dto.Config) {
+		t.update(core.SyncState(), true)
+	})
+	// 起動直後の更新がトレイ生成より先に終わっている場合の取りこぼしを防ぐ
+	if state := core.SyncState(); state.UpdatedAt != "" {
+		t.apply(state)
+	}
+	if session := core.CurrentFocus(); session != nil {
+		t.applyFocus(session)
+	}
+	return t
+}
+
+func (t *trayController) apply(state dto.SyncState) {
+	t.update(state, false)
+}
+
+// update は更新の結果をラベル/ツールチップ/アイコンに反映し、キャッシュが変わったか forceMenu ならメニューを作り直す。
+func (t *trayController) update(state dto.SyncState, forceMenu bool) {
+	t.refreshLabel()
+	switch {
+	case state.TokenInvalid:
+		t.systray.SetTooltip(coreapp.AppName + ": トークンが無効です（" + invalidAccountsLabel(state.InvalidTokenAccounts) + "。設定で保存し直してください）")
+	case state.LastError != "":
+		t.systray.SetTooltip(coreapp.AppName + ": 更新に失敗しました")
+	default:
+		t.systray.SetTooltip(coreapp.AppName)
+	}
+
+	next := tray.StateFor(state.LastError, state.Counts.Habits)
+	snapshot := t.core.Snapshot()
+	t.mu.Lock()
+	iconChanged := next != t.iconState
+	t.iconState = next
+	menuChanged := !reflect.DeepEqual(snapshot, t.snapshot)
+	t.snapshot = snapshot
+	t.mu.Unlock()
+
+	if iconChanged {
+		if icon := t.icons.For(next); icon != nil {
+			t.systray.SetIcon(icon)
+			t.systray.SetDarkModeIcon(icon)
+		}
+	}
+	if menuChanged || forceMenu {
+		t.rebuildMenu()
+	}
+}
+
+// applyFocus は集中セッションの変化をラベル/メニュー/ポップオーバーに反映する。
+// 計測中は 1 秒ごとにラベルの残り時間を更新する。
+func (t *trayController) applyFocus(session *dto.FocusSession) {
+	t.mu.Lock()
+	t.focus = session
+	if t.focusStop != nil {
+		close(t.focusStop)
+		t.focusStop = nil
+	}
+	if session != nil && !session.Paused {
+		stop := make(chan struct{})
+		t.focusStop = stop
+		go func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					t.refreshLabel()
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+	t.mu.Unlock()
+
+	t.refreshLabel()
+	t.rebuildMenu()
+	t.window.EmitEvent("focus", session)
+}
+
+// refreshLabel は集中セッション中なら残り時間を、それ以外は設定に応じたラベルを表示する。
+func (t *trayController) refreshLabel() {
+	t.mu.Lock()
+	focus := t.focus
+	t.mu.Unlock()
+	if focus != nil {
+		t.systray.SetLabel(tray.FocusLabel(*focus, time.Now()))
+		return
+	}
+	t.systray.SetLabel(tray.Label(t.core.GetConfig(), t.core.SyncState()))
+}
+
+// rebuildMenu は保持しているキャッシュと集中セッションからメニューを作り直して反映する。
+// Menu は項目のスライスを持つだけでロックがないため、作り直しと反映を menuMu の中で行う。
+func (t *trayController) rebuildMenu() {
+	t.menuMu.Lock()
+	defer t.menuMu.Unlock()
+	t.mu.Lock()
+	snapshot := t.snapshot
+	t.mu.Unlock()
+	t.buildMenu(snapshot)
+	t.menu.Update()
+}
+
+// buildMenu はキャッシュから DB ごとのサブメニューを作り、固定メニューを後ろに並べる。
+func (t *trayController) buildMenu(snapshot []dto.DatabaseSnapshot) {
+	menu := t.menu
+	menu.Clear()
+
+	cfg := t.core.GetConfig()
+	added := false
+	for _, db := range snapshot {
+		if len(db.Items) == 0 {
+			continue
+		}
+		sub := menu.AddSubmenu(fmt.Sprintf("%s (%d)", db.Name, len(db.Items)))
+		if db.Kind == dto.DatabaseKindHabit {
+			t.addHabitItems(sub, db)
+		} else {
+			dbCfg, _ := cfg.DatabaseByKey(db.Key)
+			t.addTaskItems(sub, db, dbCfg)
+		}
+		added = true
+	}
+	if added {
+		menu.AddSeparator()
+	}
+	t.mu.Lock()
+	focus := t.focus
+	t.mu.Unlock()
+	if focus != nil {
+		t.addFocusItems(menu, *focus)
+		menu.AddSeparator()
+	}
+
+	menu.Add("タスク").OnClick(func(ctx *application.Context) {
+		t.window.EmitEvent("view-change", "tasks")
+		t.window.Show()
+	})
+	menu.Add("習慣").OnClick(func(ctx *application.Context) {
+		t.window.EmitEvent("view-change", "habits")
+		t.window.Show()
+	})
+	t.addProfileItems(menu, cfg)
+	menu.Add("設定").OnClick(func(ctx *application.Context) {
+		showSettingsWindow(t.settingsWindow)
+	})
+	menu.AddSeparator()
+	menu.Add("更新").OnClick(func(ctx *application.Context) {
+		t.window.EmitEvent("refresh")
+	})
+	menu.AddSeparator()
+	menu.Add("終了").OnClick(func(ctx *application.Context) {
+		t.app.Quit()
+	})
+}
+
+// addProfileItems は設定プロファイルがあれば切り替え用のサブメニューを追加する。
+func (t *trayController) addProfileItems(menu *application.Menu, cfg dto.Config) {
+	if len(cfg.Profiles) == 0 {
+		return
+	}
+	sub := menu.AddSubmenu("プロファイル: " + cfg.ActiveProfile)
+	for _, p := range cfg.Profiles {
+		sub.AddRadio(p.Name, p.Name == cfg.ActiveProfile).OnClick(func(ctx *application.Context) {
+			go func() {
+				if err := t.core.SwitchProfile(p.Name); err != nil {
+					t.core.NotifyActionFailed("プロファイル「"+p.Name+"」に切り替えられません", err)
+				}
+			}()
+		})
+	}
+}
+
+func (t *trayController) addTaskItems(menu *application.Menu, db dto.DatabaseSnapshot, dbCfg dto.DatabaseConfig) {
+	for _, task := range db.Items {
+		label := tray.Truncate(displayTitle(task.Title), trayTitleMaxLength)
+		if task.Overdue {
+			label = "⚠ " + label
+		}
+		sub := menu.AddSubmenu(label)
+		if dbCfg.StatusDone != "" {
+			sub.Add("完了").OnClick(func(ctx *application.Context) {
+				t.runAction(db, func(ctx context.Context) error {
+					return t.core.UpdateTaskStatus(ctx, db.Key, task.ID, "done")
+				})
+			})
+		}
+		if dbCfg.StatusPaused != "" {
+			sub.Add("中断").OnClick(func(ctx *application.Context) {
+				t.runAction(db, func(ctx context.Context) error {
+					return t.core.UpdateTaskStatus(ctx, db.Key, task.ID, "paused")
+				})
+			})
+		}
+		sub.Add("集中を開始").OnClick(func(ctx *application.Context) {
+			if _, err := t.core.StartFocus(db.Key, task.ID); err != nil {
+				log.Printf("tray focus: %v", err)
+			}
+		}).SetEnabled(t.core.CurrentFocus() == nil)
+		sub.Add("Notionで開く").OnClick(func(ctx *application.Context) {
+			t.openURL(task.URL)
+		}).SetEnabled(task.URL != "")
+	}
+}
+
+func (t *trayController) addFocusItems(menu *application.Menu, focus dto.FocusSession) {
+	menu.Add("集中: " + tray.Truncate(displayTitle(focus.TaskTitle), trayTitleMaxLength)).SetEnabled(false)
+	if focus.Paused {
+		menu.Add("再開").OnClick(func(ctx *application.Context) {
+			if _, err := t.core.ResumeFocus(); err != nil {
+				log.Printf("tray focus: %v", err)
+			}
+		})
+	} else {
+		menu.Add("一時停止").OnClick(func(ctx *application.Context) {
+			if _, err := t.core.PauseFocus(); err != nil {
+				log.Printf("tray focus: %v", err)
+			}
+		})
+	}
+	menu.Add("終了して記録").OnClick(func(ctx *application.Context) {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), trayActionTimeout)
+			defer cancel()
+			if err := t.core.FinishFocus(ctx); err != nil {
+				log.Printf("tray focus: %v", err)
+			}
+		}()
+	})
+	menu.Add("破棄").OnClick(func(ctx *application.Context) {
+		if err := t.core.CancelFocus(); err != nil {
+			log.Printf("tray focus: %v", err)
+		}
+	})
+}
+
+func (t *trayController) addHabitItems(menu *application.Menu, db dto.DatabaseSnapshot) {
+	for _, habit := range db.Items {
+		menu.Add("☐ " + tray.Truncate(displayTitle(habit.Title), trayTitleMaxLength)).OnClick(func(ctx *application.Context) {
+			t.runAction(db, func(ctx context.Context) error {
+				return t.core.UpdateHabitCheck(ctx, db.Key, habit.ID, true)
+			})
+		})
+	}
+}
+
+// runAction はメニュー操作を UI スレッド外で実行し、対象 DB を取り直してメニューとポップオーバーを更新する。
+func (t *trayController) runAction(db dto.DatabaseSnapshot, action func(ctx context.Context) error) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), trayActionTimeout)
+		defer cancel()
+		if err := action(ctx); err != nil {
+			log.Printf("tray action: db=%s err=%v", db.Key, err)
+			return
+		}
+		var err error
+		if db.Kind == dto.DatabaseKindHabit {
+			_, err = t.core.GetHabits(ctx, db.Key, true)
+		} else {
+			_, err = t.core.GetTasks(ctx, db.Key, true)
+		}
+		if err != nil {
+			log.Printf("tray reload: db=%s err=%v", db.Key, err)
+		}
+		t.window.EmitEvent("refresh")
+	}()
+}
+
+func (t *trayController) openURL(url string) {
+	if url == "" {
+		return
+	}
+	if err := t.app.Browser.OpenURL(url); err != nil {
+		log.Printf("tray open url: %v", err)
+	}
+}
+
+func displayTitle(title string) string {
+	if strings.TrimSpace(title) == "" {
+		return "(無題)"
+	}
+	return title
+}
+
+func loadTrayIcon(cfg dto.Config) []byte {
+	if strings.TrimSpace(cfg.TrayIconPath) != "" {
+		path := resolveTrayIconPath(cfg.TrayIconPath)
+		b, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("tray icon: read failed: path=%s err=%v", path, err)
+		} else if len(b) > 0 {
+			return b
+		}
+	}
+	// フォールバック（埋め込み base64）
+	icon, err := base64.StdEncoding.DecodeString(trayIconBase64)
+	if err != nil {
+		log.Printf("tray icon: base64 decode failed: %v", err)
+		return nil
+	}
+	return icon
+}
+
+func resolveTrayIconPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(base, coreapp.AppName, path)
+}
+
+// invalidAccountsLabel はトークンが無効なアカウントをツールチップ用に並べる。空のアカウントは既定のトークン。
+func invalidAccountsLabel(accounts []string) string {
+	labels := make([]string, len(accounts))
+	for i, account := range accounts {
+		if account == "" {
+			account = "既定"
+		}
+		labels[i] = account
+	}
+	return strings.Join(labels, ", ")
+}
@@ -0,0 +1,13 @@
+// assets の JS を tsc --checkJs で検査するための宣言（task common:check:js）。
+// DOM には型を付けず、app.js の RPC 呼び出しと生成したクライアント（rpc-client.js）の食い違いを検査する。
+
+declare const window: any;
+declare const document: any;
+declare const crypto: any;
+declare const URLSearchParams: any;
+
+declare function confirm(message?: string): boolean;
+declare function setTimeout(handler: () => void, timeout?: number): any;
+declare function clearTimeout(id: any): void;
+declare function setInterval(handler: () => void, timeout?: number): any;
+declare function clearInterval(id: any): void;
@@ -2,7 +2,10 @@ module nudge
 
 go 1.24.0
 
-require github.com/wailsapp/wails/v3 v3.0.0-alpha.53
+require (
+	github.com/godbus/dbus/v5 v5.1.0
+	github.com/wailsapp/wails/v3 v3.0.0-alpha.53
+)
 
 require (
 	dario.cat/mergo v1.0.1 // indirect
@@ -18,7 +21,6 @@ require (
 	github.com/go-git/go-billy/v5 v5.6.2 // indirect
 	github.com/go-git/go-git/v5 v5.13.2 // indirect
 	github.com/go-ole/go-ole v1.3.0 // indirect
-	github.com/godbus/dbus/v5 v5.1.0 // indirect
 	github.com/golang/groupcache v0.0.0-20241129210726-2c02b8208cf8 // indirect
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/jbenet/go-context v0.0.0-20150711004518-d14ea06fba99 // indirect
@@ -2,13 +2,20 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"nudge/internal/dto"
 	"nudge/internal/notion"
+	"nudge/internal/notion/notionid"
 	"nudge/internal/store"
 	syncer "nudge/internal/sync"
 )
@@ -25,68 +32,200 @@ type App struct {
 	taskCache    map[string][]dto.Task
 	habitCache   map[string][]dto.Task
 
-	mu  sync.Mutex
-	cfg dto.Config
+	stateMu        sync.Mutex
+	state          dto.SyncState
+	stateListeners []func(dto.SyncState)
+	// invalidAccounts は Notion にトークンを拒否されたアカウント ID（空は既定のトークン）。その DB は取得しない
+	invalidAccounts map[string]struct{}
+
+	notifications *notificationTracker
+	reminders     *syncer.Scheduler
+
+	focusMu        sync.Mutex
+	focus          *dto.FocusSession
+	focusTimer     *time.Timer
+	focusListeners []func(*dto.FocusSession)
+	ledger         *store.TimeLedger
+
+	history *store.ActionHistory
+
+	brain      *store.BrainStore
+	workspaces *store.WorkspaceStore
+	draftMu    sync.Mutex
+	drafts     map[string]dto.BrainDraft
+	draftTimer *time.Timer
+
+	mu              sync.Mutex
+	cfg             dto.Config
+	cfgLoaded       bool
+	configListeners []func(dto.Config)
 }
 
 func NewApp(cfgStore store.ConfigStore, tokenStore store.TokenStore, notionClient *notion.Client) *App {
-	return &App{
+	a := &App{
 		cfgStore:   cfgStore,
 		tokenStore: tokenStore,
 		notion:     notionClient,
 		taskCache:  make(map[string][]dto.Task),
 		habitCache: make(map[string][]dto.Task),
+
+		invalidAccounts: make(map[string]struct{}),
+		notifications:   newNotificationTracker(),
 	}
+	a.reminders = newReminderScheduler(a)
+	a.ledger = store.NewTimeLedger(a.stateFilePath(ledgerFile))
+	a.history = store.NewActionHistory(a.stateFilePath(historyFile))
+	a.brain = store.NewBrainStore(a.stateFilePath(brainDraftFile), a.stateFilePath(brainNotesFile))
+	a.workspaces = store.NewWorkspaceStore(a.stateFilePath(workspacesFile))
+	return a
 }
 
+// LoadConfig は設定ファイルを読み込み直して差し替える。前回と変わっていれば applyConfig で反映する。
 func (a *App) LoadConfig() (dto.Config, error) {
 	cfg, err := a.cfgStore.Load()
 	if err != nil {
 		return cfg, err
 	}
 	cfg = cfg.Normalize()
+	a.swapConfig(cfg)
+	return cfg, nil
+}
+
+// reloadConfig は外部で編集された設定ファイルを検証してから差し替える。誤りがあれば直前の設定のままにする。
+func (a *App) reloadConfig() error {
+	cfg, err := a.cfgStore.Load()
+	if err != nil {
+		return err
+	}
+	if errs := cfg.Validate(); len(errs) > 0 {
+		return errs
+	}
+	a.swapConfig(cfg.Normalize())
+	return nil
+}
+
+func (a *App) swapConfig(cfg dto.Config) {
+	a.useTokenAccount(cfg)
 	a.mu.Lock()
-	defer a.mu.Unlock()
+	prev, loaded := a.cfg, a.cfgLoaded
 	a.cfg = cfg
-	return cfg, nil
+	a.cfgLoaded = true
+	a.mu.Unlock()
+	if loaded && !reflect.DeepEqual(prev, cfg) {
+		a.applyConfig(prev, cfg)
+	}
 }
 
-func (a *App) GetConfig() dto.Config {
+// WatchConfig は設定ファイルの外部での変更を監視し、変わったら読み込み直す。ストアが監視に対応していなければ何もしない。
+func (a *App) WatchConfig(ctx context.Context) {
+	watcher, ok := a.cfgStore.(store.ConfigWatcher)
+	if !ok {
+		return
+	}
+	go watcher.Watch(ctx, configWatchInterval, func() {
+		if err := a.reloadConfig(); err != nil {
+			// 壊れた設定は取り込まず、直前の設定のまま動かし続ける
+			slog.Warn("reload config failed", "error", err)
+		}
+	})
+}
+
+// AddConfigListener は設定ファイルの変更を読み込んで反映した後に呼ばれるリスナーを登録する。
+func (a *App) AddConfigListener(fn func(dto.Config)) {
+	if fn == nil {
+		return
+	}
 	a.mu.Lock()
 	defer a.mu.Unlock()
-	return a.cfg
+	a.configListeners = append(a.configListeners, fn)
 }
 
-func (a *App) SaveConfig(cfg dto.Config) error {
-	cfg = cfg.Normalize()
-	prev := a.currentConfig()
+// applyConfig は読み込み直した設定のうち、再起動が必要なものを反映してリスナーに通知する。
+func (a *App) applyConfig(prev, cfg dto.Config) {
 	if prev.LaunchAtLogin != cfg.LaunchAtLogin {
 		if err := setLaunchAtLogin(cfg.LaunchAtLogin); err != nil {
-			return err
+			slog.Warn("apply launch at login failed", "error", err)
 		}
 	}
-	if err := a.cfgStore.Save(cfg); err != nil {
-		return err
+	pruned := a.pruneInvalidAccounts(cfg)
+	changed := pollingConfigChanged(prev, cfg) || prev.ActiveTokenAccount() != cfg.ActiveTokenAccount()
+	if changed && a.pollingActive() {
+		a.StartBackgroundPolling()
+	} else if pruned {
+		a.notifyCacheChanged()
 	}
+	a.publishConfig(cfg)
+}
+
+func (a *App) publishConfig(cfg dto.Config) {
 	a.mu.Lock()
-	a.cfg = cfg
+	listeners := append([]func(dto.Config){}, a.configListeners...)
 	a.mu.Unlock()
-	return nil
+	for _, fn := range listeners {
+		fn(cfg)
+	}
+}
+
+// useTokenAccount は有効なプロファイルが参照するトークンに切り替える。
+func (a *App) useTokenAccount(cfg dto.Config) {
+	if switcher, ok := a.tokenStore.(store.AccountSwitcher); ok {
+		switcher.UseAccount(cfg.ActiveTokenAccount())
+	}
+}
+
+// pollingConfigChanged は取得対象・間隔・リマインダーなど、ポーリングのやり直しが必要な変更があるかを返す。
+func pollingConfigChanged(prev, cfg dto.Config) bool {
+	return !slices.Equal(prev.Databases, cfg.Databases) ||
+		prev.PollIntervalSeconds != cfg.PollIntervalSeconds ||
+		prev.MaxResults != cfg.MaxResults ||
+		prev.NotionVersion != cfg.NotionVersion ||
+		prev.DueReminders != cfg.DueReminders ||
+		prev.DailyReview != cfg.DailyReview
+}
+
+func (a *App) GetConfig() dto.Config {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.cfg
 }
 
-func (a *App) GetToken() (string, error) {
-	return a.tokenStore.GetToken()
+// ValidateConfig は保存前の設定を検証し、誤りのある項目を返す。
+func (a *App) ValidateConfig(cfg dto.Config) []dto.FieldError {
+	errs := cfg.Validate()
+	if errs == nil {
+		return []dto.FieldError{}
+	}
+	return errs
 }
 
-func (a *App) SetToken(token string) error {
-	if token == "" {
-		return fmt.Errorf("token is empty")
+// SaveConfig は設定を検証して保存し、保存後の Revision を返す。誤りがあれば dto.ValidationErrors を返す。
+// cfg.Revision は読み込んだときの値のまま渡す。その後に設定ファイルが書き換えられていれば store.ErrConfigConflict になる。
+func (a *App) SaveConfig(cfg dto.Config) (string, error) {
+	if errs := cfg.Validate(); len(errs) > 0 {
+		return "", errs
 	}
-	return a.tokenStore.SetToken(token)
+	return a.saveConfig(cfg)
 }
 
-func (a *App) ClearToken() error {
-	return a.tokenStore.ClearToken()
+// saveConfig は検証済みの設定を保存して差し替える。
+func (a *App) saveConfig(cfg dto.Config) (string, error) {
+	cfg = cfg.Normalize()
+	prev := a.currentConfig()
+	if prev.LaunchAtLogin != cfg.LaunchAtLogin {
+		if err := setLaunchAtLogin(cfg.LaunchAtLogin); err != nil {
+			return "", err
+		}
+	}
+	revision, err := a.cfgStore.Save(cfg)
+	if err != nil {
+		return "", err
+	}
+	cfg.Revision = revision
+	a.useTokenAccount(cfg)
+	a.mu.Lock()
+	a.cfg = cfg
+	a.mu.Unlock()
+	return revision, nil
 }
 
 func (a *App) RefreshTasks(ctx context.Context) ([]dto.Task, error) {
@@ -108,7 +247,12 @@ func (a *App) UpdateTaskStatus(ctx context.Context, databaseKey, taskID string,
 	if statusValue == "" {
 		return fmt.Errorf("status is not configured")
 	}
-	return a.notion.UpdateStatus(ctx, taskID, db, cfg.NotionVersion, statusValue)
+	if err := a.notion.ForAccount(db.Account).UpdateStatus(ctx, taskID, db, cfg.NotionVersion, statusValue); err != nil {
+		return err
+	}
+	a.notifications.markLocalEdit(taskID)
+	a.recordAction(taskAction(action), db, taskID)
+	return nil
 }
 
 func (a *App) QueryTasks(ctx context.Context, databaseKey string) ([]dto.Task, error) {
@@ -119,7 +263,15 @@ func (a *App) QueryTasks(ctx context.Context, databaseKey string) ([]dto.Task, e
 	if db.Kind != dto.DatabaseKindTask {
 		return nil, fmt.Errorf("database kind is not task")
 	}
-	return a.notion.QueryByStatus(ctx, db, cfg.NotionVersion, cfg.MaxResults, db.StatusInProgress)
+	db, err = a.ensureDataSource(ctx, db, cfg.NotionVersion)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := a.notion.ForAccount(db.Account).QueryByStatus(ctx, db, cfg.NotionVersion, cfg.MaxResults, db.StatusInProgress)
+	if err != nil {
+		return nil, err
+	}
+	return markOverdue(tasks, time.Now()), nil
 }
 
 func (a *App) GetTasks(ctx context.Context, databaseKey string, force bool) ([]dto.Task, error) {
@@ -132,7 +284,9 @@ func (a *App) GetTasks(ctx context.Context, databaseKey string, force bool) ([]d
 	if err != nil {
 		return nil, err
 	}
-	a.setTaskCache(databaseKey, tasks)
+	if a.setTaskCache(databaseKey, tasks) {
+		a.notifyCacheChanged()
+	}
 	return tasks, nil
 }
 
@@ -152,7 +306,7 @@ func (a *App) QueryHabits(ctx context.Context, databaseKey string) ([]dto.Task,
 	if err != nil {
 		return nil, err
 	}
-	tasks, err := a.notion.QueryHabitsToday(ctx, db, checkboxPropertyName, cfg.NotionVersion, cfg.MaxResults)
+	tasks, err := a.notion.ForAccount(db.Account).QueryHabitsToday(ctx, db, checkboxPropertyName, cfg.NotionVersion, cfg.MaxResults)
 	if err != nil {
 		return nil, err
 	}
@@ -169,7 +323,9 @@ func (a *App) GetHabits(ctx context.Context, databaseKey string, force bool) ([]
 	if err != nil {
 		return nil, err
 	}
-	a.setHabitCache(databaseKey, habits)
+	if a.setHabitCache(databaseKey, habits) {
+		a.notifyCacheChanged()
+	}
 	return habits, nil
 }
 
@@ -192,36 +348,112 @@ func (a *App) UpdateHabitCheck(ctx context.Context, databaseKey, taskID string,
 	if err != nil {
 		return err
 	}
-	return a.notion.UpdateCheckbox(ctx, taskID, db, checkboxPropertyName, cfg.NotionVersion, checked)
+	if err := a.notion.ForAccount(db.Account).UpdateCheckbox(ctx, taskID, db, checkboxPropertyName, cfg.NotionVersion, checked); err != nil {
+		return err
+	}
+	action := dto.ActionHabitUnchecked
+	if checked {
+		action = dto.ActionHabitChecked
+	}
+	a.recordAction(action, db, taskID)
+	return nil
 }
 
-func (a *App) ResolveDataSourceID(ctx context.Context, databaseID string) (string, error) {
+// ResolveDataSourceID は Database の ID（または Notion の URL）から、account のトークンで Data Source ID を解決する。
+func (a *App) ResolveDataSourceID(ctx context.Context, databaseID, account string) (string, error) {
+	if err := dto.ValidateTokenAccount(account); err != nil {
+		return "", err
+	}
+	id, err := notionid.Parse(databaseID)
+	if err != nil {
+		return "", err
+	}
 	cfg := a.currentConfig()
-	return a.notion.ResolveDataSourceID(ctx, databaseID, cfg.NotionVersion)
+	return a.notion.ForAccount(strings.TrimSpace(account)).ResolveDataSourceID(ctx, id, cfg.NotionVersion)
 }
 
-func (a *App) ResolveTitlePropertyName(ctx context.Context, databaseID string) (string, error) {
+// ResolveTitlePropertyName は Database の ID（または Notion の URL）から、account のトークンでタイトルプロパティ名を解決する。
+func (a *App) ResolveTitlePropertyName(ctx context.Context, databaseID, account string) (string, error) {
+	if err := dto.ValidateTokenAccount(account); err != nil {
+		return "", err
+	}
+	id, err := notionid.Parse(databaseID)
+	if err != nil {
+		return "", err
+	}
 	cfg := a.currentConfig()
-	return a.notion.ResolveTitlePropertyName(ctx, databaseID, cfg.NotionVersion)
+	return a.notion.ForAccount(strings.TrimSpace(account)).ResolveTitlePropertyName(ctx, id, cfg.NotionVersion)
 }
 
-func (a *App) GetBrainTemplate(ctx context.Context) (dto.BrainTemplate, error) {
-	cfg := a.currentConfig()
-	if strings.TrimSpace(cfg.BrainTemplatePageID) == "" {
-		return dto.BrainTemplate{}, fmt.Errorf("brain_template_page_id is required")
+// GetBrainTemplate は Brain プロファイルのテンプレートを返す。profileKey が空なら先頭のプロファイルを使う。
+func (a *App) GetBrainTemplate(ctx context.Context, profileKey string) (dto.BrainTemplate, error) {
+	profile, cfg, err := a.resolveBrainProfile(profileKey)
+	if err != nil {
+		return dto.BrainTemplate{}, err
 	}
-	return a.notion.FetchTemplate(ctx, cfg.BrainTemplatePageID, cfg.NotionVersion)
+	return a.notion.ForAccount(profile.Account).FetchTemplate(ctx, profile.TemplatePageID, cfg.NotionVersion)
 }
 
-func (a *App) CreateBrainPage(ctx context.Context, body string) (dto.CreatedPage, error) {
+// CreateBrainPage は Brain プロファイルの DB にテンプレート起点でページを作成する。
+func (a *App) CreateBrainPage(ctx context.Context, profileKey, body string) (dto.CreatedPage, error) {
+	profile, cfg, err := a.resolveBrainProfile(profileKey)
+	if err != nil {
+		return dto.CreatedPage{}, err
+	}
+	opts := notion.PageOptions{Title: profile.PageTitle(time.Now()), Properties: profile.Properties}
+	page, err := a.notion.ForAccount(profile.Account).CreatePageFromTemplate(ctx, profile.DatabaseID, profile.TemplatePageID, body, opts, cfg.NotionVersion)
+	if err != nil {
+		return page, err
+	}
+	a.recordBrainNote(profile.Key, body, page)
+	return page, nil
+}
+
+func (a *App) resolveBrainProfile(key string) (dto.BrainProfile, dto.Config, error) {
 	cfg := a.currentConfig()
-	if strings.TrimSpace(cfg.BrainDatabaseID) == "" {
-		return dto.CreatedPage{}, fmt.Errorf("brain_database_id is required")
+	profile, ok := cfg.BrainProfileByKey(key)
+	if !ok {
+		if key == "" {
+			return dto.BrainProfile{}, cfg, fmt.Errorf("brain_profiles is empty")
+		}
+		return dto.BrainProfile{}, cfg, fmt.Errorf("brain profile not found: %s", key)
+	}
+	if profile.DatabaseID == "" {
+		return dto.BrainProfile{}, cfg, fmt.Errorf("database_id is required for brain profile %s", profile.Key)
 	}
-	if strings.TrimSpace(cfg.BrainTemplatePageID) == "" {
-		return dto.CreatedPage{}, fmt.Errorf("brain_template_page_id is required")
+	if profile.TemplatePageID == "" {
+		return dto.BrainProfile{}, cfg, fmt.Errorf("template_page_id is required for brain profile %s", profile.Key)
 	}
-	return a.notion.CreatePageFromTemplate(ctx, cfg.BrainDatabaseID, cfg.BrainTemplatePageID, body, cfg.NotionVersion)
+	return profile, cfg, nil
+}
+
+// Refresh は有効な全 DB を即時に取得し直してキャッシュを更新する。
+// トークンを拒否されたアカウントの DB も取得し直し、通れば無効の記録を消す。
+func (a *App) Refresh(ctx context.Context) error {
+	a.stateMu.Lock()
+	clear(a.invalidAccounts)
+	a.stateMu.Unlock()
+	return a.refreshAll(ctx)
+}
+
+// Counts はキャッシュ済みの進行中タスクと未チェック習慣の件数を返す。
+func (a *App) Counts() dto.Counts {
+	cfg := a.currentConfig()
+	a.cacheMu.Lock()
+	defer a.cacheMu.Unlock()
+	var counts dto.Counts
+	for _, db := range cfg.Databases {
+		if !db.Enabled {
+			continue
+		}
+		switch db.Kind {
+		case dto.DatabaseKindHabit:
+			counts.Habits += len(a.habitCache[db.Key])
+		default:
+			counts.Tasks += len(a.taskCache[db.Key])
+		}
+	}
+	return counts
 }
 
 func (a *App) StartPolling(ctx context.Context, refresh func([]dto.Task)) error {
@@ -272,12 +504,20 @@ func (a *App) StartBackgroundPolling() {
 	a.poller = p
 	a.pollerCancel = cancel
 
+	// リマインダーはポーリングの再起動と独立して動かし続ける
+	a.reminders.Start(context.Background())
 	go func() {
 		_ = a.refreshAll(ctx)
 	}()
 	p.Start(ctx)
 }
 
+func (a *App) pollingActive() bool {
+	a.pollerMu.Lock()
+	defer a.pollerMu.Unlock()
+	return a.poller != nil
+}
+
 func (a *App) StopPolling() {
 	a.pollerMu.Lock()
 	defer a.pollerMu.Unlock()
@@ -296,6 +536,29 @@ func (a *App) stopPollingLocked() {
 	}
 }
 
+// stateFilePath は設定ファイルと同じディレクトリに置く状態ファイルのパスを返す。解決できなければ空。
+func (a *App) stateFilePath(name string) string {
+	path, err := a.cfgStore.Path()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(path), name)
+}
+
+// exportDir は書き出したファイルの保存先（ダウンロードフォルダ、なければ設定ディレクトリ）を返す。
+func (a *App) exportDir() (string, error) {
+	if home, err := os.UserHomeDir(); err == nil {
+		if info, err := os.Stat(filepath.Join(home, "Downloads")); err == nil && info.IsDir() {
+			return filepath.Join(home, "Downloads"), nil
+		}
+	}
+	path, err := a.cfgStore.Path()
+	if err != nil {
+		return "", fmt.Errorf("export directory unavailable")
+	}
+	return filepath.Dir(path), nil
+}
+
 func (a *App) currentConfig() dto.Config {
 	a.mu.Lock()
 	defer a.mu.Unlock()
@@ -303,31 +566,43 @@ func (a *App) currentConfig() dto.Config {
 }
 
 func (a *App) refreshAll(ctx context.Context) error {
+	err := a.refreshDatabases(ctx)
+	a.publishState(err)
+	a.evaluateNotifications(ctx, err)
+	a.scheduleDueReminders(time.Now())
+	return err
+}
+
+func (a *App) refreshDatabases(ctx context.Context) error {
 	a.refreshMu.Lock()
 	defer a.refreshMu.Unlock()
 
 	cfg := a.currentConfig()
 	var firstErr error
+	fail := func(db dto.DatabaseConfig, err error) {
+		if errors.Is(err, notion.ErrUnauthorized) {
+			a.markTokenInvalid(tokenAccount(cfg, db.Account), err)
+		}
+		if firstErr == nil || errors.Is(err, notion.ErrUnauthorized) {
+			firstErr = err
+		}
+	}
 	for _, db := range cfg.Databases {
-		if !db.Enabled {
+		if !db.Enabled || a.tokenInvalid(tokenAccount(cfg, db.Account)) {
 			continue
 		}
 		switch db.Kind {
 		case dto.DatabaseKindHabit:
 			habits, err := a.QueryHabits(ctx, db.Key)
 			if err != nil {
-				if firstErr == nil {
-					firstErr = err
-				}
+				fail(db, err)
 				continue
 			}
 			a.setHabitCache(db.Key, habits)
 		default:
 			tasks, err := a.QueryTasks(ctx, db.Key)
 			if err != nil {
-				if firstErr == nil {
-					firstErr = err
-				}
+				fail(db, err)
 				continue
 			}
 			a.setTaskCache(db.Key, tasks)
@@ -336,6 +611,105 @@ func (a *App) refreshAll(ctx context.Context) error {
 	return firstErr
 }
 
+// AddStateListener は refreshAll の完了時とキャッシュ変更時に呼ばれるリスナーを登録する。
+func (a *App) AddStateListener(fn func(dto.SyncState)) {
+	if fn == nil {
+		return
+	}
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	a.stateListeners = append(a.stateListeners, fn)
+}
+
+// SyncState は直近の更新結果を返す。
+func (a *App) SyncState() dto.SyncState {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	return a.state
+}
+
+// Snapshot は有効な DB ごとのキャッシュ内容を設定順に返す。
+func (a *App) Snapshot() []dto.DatabaseSnapshot {
+	cfg := a.currentConfig()
+	a.cacheMu.Lock()
+	defer a.cacheMu.Unlock()
+	out := make([]dto.DatabaseSnapshot, 0, len(cfg.Databases))
+	for _, db := range cfg.Databases {
+		if !db.Enabled {
+			continue
+		}
+		items := a.taskCache[db.Key]
+		if db.Kind == dto.DatabaseKindHabit {
+			items = a.habitCache[db.Key]
+		}
+		out = append(out, dto.DatabaseSnapshot{
+			Key:   db.Key,
+			Name:  db.Name,
+			Kind:  db.Kind,
+			Items: cloneTasks(items),
+		})
+	}
+	return out
+}
+
+func (a *App) publishState(refreshErr error) {
+	lastError := ""
+	if refreshErr != nil {
+		lastError = refreshErr.Error()
+	}
+	a.publish(lastError)
+}
+
+// notifyCacheChanged は refreshAll 以外でキャッシュが変わったときに、直近のエラー状態を保ったまま通知する。
+func (a *App) notifyCacheChanged() {
+	a.stateMu.Lock()
+	lastError := a.state.LastError
+	a.stateMu.Unlock()
+	a.publish(lastError)
+}
+
+func (a *App) publish(lastError string) {
+	state := dto.SyncState{
+		Counts:    a.Counts(),
+		TopTask:   a.topTask(),
+		LastError: lastError,
+		UpdatedAt: time.Now().Format(time.RFC3339),
+	}
+	a.stateMu.Lock()
+	for account := range a.invalidAccounts {
+		state.InvalidTokenAccounts = append(state.InvalidTokenAccounts, account)
+	}
+	slices.Sort(state.InvalidTokenAccounts)
+	state.TokenInvalid = len(state.InvalidTokenAccounts) > 0
+	a.state = state
+	listeners := append([]func(dto.SyncState){}, a.stateListeners...)
+	a.stateMu.Unlock()
+	for _, fn := range listeners {
+		fn(state)
+	}
+}
+
+// topTask は有効なタスク DB のうち最終更新が最も新しい進行中タスクを返す。
+func (a *App) topTask() *dto.Task {
+	cfg := a.currentConfig()
+	a.cacheMu.Lock()
+	defer a.cacheMu.Unlock()
+	var top *dto.Task
+	for _, db := range cfg.Databases {
+		if !db.Enabled || db.Kind != dto.DatabaseKindTask {
+			continue
+		}
+		for _, task := range a.taskCache[db.Key] {
+			// last_edited_time は ISO8601（UTC）なので文字列比較で新旧を判定できる
+			if top == nil || task.LastEditedTime > top.LastEditedTime {
+				t := task
+				top = &t
+			}
+		}
+	}
+	return top
+}
+
 func (a *App) getTaskCache(key string) ([]dto.Task, bool) {
 	a.cacheMu.Lock()
 	defer a.cacheMu.Unlock()
@@ -346,10 +720,13 @@ func (a *App) getTaskCache(key string) ([]dto.Task, bool) {
 	return cloneTasks(tasks), true
 }
 
-func (a *App) setTaskCache(key string, tasks []dto.Task) {
+// setTaskCache はキャッシュを更新し、内容が変わったかを返す。
+func (a *App) setTaskCache(key string, tasks []dto.Task) bool {
 	a.cacheMu.Lock()
 	defer a.cacheMu.Unlock()
+	prev, ok := a.taskCache[key]
 	a.taskCache[key] = cloneTasks(tasks)
+	return !ok || !slices.EqualFunc(prev, tasks, dto.Task.Equal)
 }
 
 func (a *App) getHabitCache(key string) ([]dto.Task, bool) {
@@ -362,10 +739,13 @@ func (a *App) getHabitCache(key string) ([]dto.Task, bool) {
 	return cloneTasks(habits), true
 }
 
-func (a *App) setHabitCache(key string, habits []dto.Task) {
+// setHabitCache はキャッシュを更新し、内容が変わったかを返す。
+func (a *App) setHabitCache(key string, habits []dto.Task) bool {
 	a.cacheMu.Lock()
 	defer a.cacheMu.Unlock()
+	prev, ok := a.habitCache[key]
 	a.habitCache[key] = cloneTasks(habits)
+	return !ok || !slices.EqualFunc(prev, habits, dto.Task.Equal)
 }
 
 func cloneTasks(tasks []dto.Task) []dto.Task {
@@ -458,22 +838,32 @@ func filterUnchecked(tasks []dto.Task) []dto.Task {
 	return out
 }
 
+// ensureDataSource は data_source_id が未設定なら database_id から解決する。設定ファイルには書き戻さない。
+func (a *App) ensureDataSource(ctx context.Context, db dto.DatabaseConfig, notionVersion string) (dto.DatabaseConfig, error) {
+	if db.DataSourceID != "" {
+		return db, nil
+	}
+	if strings.TrimSpace(db.DatabaseID) == "" {
+		return db, fmt.Errorf("database_id is required")
+	}
+	id, err := a.notion.ForAccount(db.Account).ResolveDataSourceID(ctx, db.DatabaseID, notionVersion)
+	if err != nil {
+		return db, err
+	}
+	db.DataSourceID = id
+	return db, nil
+}
+
 func (a *App) ensureHabitDatabase(ctx context.Context, db dto.DatabaseConfig, notionVersion string) (dto.DatabaseConfig, error) {
-	if db.DataSourceID == "" {
-		if strings.TrimSpace(db.DatabaseID) == "" {
-			return db, fmt.Errorf("database_id is required")
-		}
-		id, err := a.notion.ResolveDataSourceID(ctx, db.DatabaseID, notionVersion)
-		if err != nil {
-			return db, err
-		}
-		db.DataSourceID = id
+	db, err := a.ensureDataSource(ctx, db, notionVersion)
+	if err != nil {
+		return db, err
 	}
 	if strings.TrimSpace(db.TitlePropertyName) == "" {
 		if strings.TrimSpace(db.DatabaseID) == "" {
 			return db, fmt.Errorf("database_id is required")
 		}
-		name, err := a.notion.ResolveTitlePropertyName(ctx, db.DatabaseID, notionVersion)
+		name, err := a.notion.ForAccount(db.Account).ResolveTitlePropertyName(ctx, db.DatabaseID, notionVersion)
 		if err != nil {
 			return db, err
 		}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"nudge/internal/dto"
+)
+
+const (
+	brainDraftFile = "brain_drafts.json"
+	brainNotesFile = "brain_notes.json"
+	// brainDraftSaveDelay は最後の入力から下書きをファイルに書くまでの待ち時間。
+	brainDraftSaveDelay = 2 * time.Second
+	maxBrainNotes       = 20
+	brainExcerptRunes   = 80
+)
+
+// BrainDraft はプロファイルの下書きを返す。profileKey が空なら先頭のプロファイル。
+func (a *App) BrainDraft(profileKey string) dto.BrainDraft {
+	key := a.brainDraftKey(profileKey)
+	a.draftMu.Lock()
+	defer a.draftMu.Unlock()
+	a.loadDraftsLocked()
+	draft, ok := a.drafts[key]
+	if !ok {
+		return dto.BrainDraft{Profile: key}
+	}
+	return draft
+}
+
+// SaveBrainDraft は下書きを更新し、入力が止まってから状態ファイルに書き込む。空の本文は下書きを消す。
+func (a *App) SaveBrainDraft(profileKey, body string) {
+	key := a.brainDraftKey(profileKey)
+	a.draftMu.Lock()
+	defer a.draftMu.Unlock()
+	a.loadDraftsLocked()
+	if strings.TrimSpace(body) == "" {
+		delete(a.drafts, key)
+	} else {
+		a.drafts[key] = dto.BrainDraft{Profile: key, Body: body, UpdatedAt: time.Now().Format(time.RFC3339)}
+	}
+	if a.draftTimer != nil {
+		a.draftTimer.Stop()
+	}
+	a.draftTimer = time.AfterFunc(brainDraftSaveDelay, a.FlushBrainDrafts)
+}
+
+// FlushBrainDrafts は保留中の下書きをすぐに書き込む。終了時にも呼ぶ。
+func (a *App) FlushBrainDrafts() {
+	a.draftMu.Lock()
+	defer a.draftMu.Unlock()
+	a.flushDraftsLocked()
+}
+
+// BrainNotes は Brain ウィンドウから登録したメモの履歴を新しい順に返す。
+func (a *App) BrainNotes() ([]dto.BrainNote, error) {
+	return a.brain.Notes()
+}
+
+// recordBrainNote は登録に成功した本文の下書きを消し、履歴に残す。
+func (a *App) recordBrainNote(profileKey, body string, page dto.CreatedPage) {
+	a.draftMu.Lock()
+	a.loadDraftsLocked()
+	delete(a.drafts, profileKey)
+	a.flushDraftsLocked()
+	a.draftMu.Unlock()
+
+	note := dto.BrainNote{
+		Profile:   profileKey,
+		Excerpt:   brainExcerpt(body),
+		PageID:    page.ID,
+		URL:       page.URL,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if err := a.brain.AddNote(note, maxBrainNotes); err != nil {
+		slog.Warn("append brain note failed", "error", err)
+	}
+}
+
+// brainDraftKey は空のキーを先頭のプロファイルのキーに置き換える。
+func (a *App) brainDraftKey(profileKey string) string {
+	if profile, ok := a.currentConfig().BrainProfileByKey(profileKey); ok {
+		return profile.Key
+	}
+	return profileKey
+}
+
+func (a *App) loadDraftsLocked() {
+	if a.drafts != nil {
+		return
+	}
+	drafts, err := a.brain.Drafts()
+	if err != nil {
+		slog.Warn("read brain drafts failed", "error", err)
+	}
+	if drafts == nil {
+		drafts = make(map[string]dto.BrainDraft)
+	}
+	a.drafts = drafts
+}
+
+func (a *App) flushDraftsLocked() {
+	if a.draftTimer != nil {
+		a.draftTimer.Stop()
+		a.draftTimer = nil
+	}
+	if a.drafts == nil {
+		return
+	}
+	if err := a.brain.SaveDrafts(a.drafts); err != nil {
+		slog.Warn("write brain drafts failed", "error", err)
+	}
+}
+
+// brainExcerpt は本文の最初の空でない行を履歴の表示用に切り詰める。
+func brainExcerpt(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		runes := []rune(line)
+		if len(runes) > brainExcerptRunes {
+			return string(runes[:brainExcerptRunes]) + "…"
+		}
+		return line
+	}
+	return ""
+}
@@ -1,7 +1,15 @@
 package app
 
+import "time"
+
 const (
 	AppName         = "Nudge"
 	KeychainService = "nudge-notion"
 	KeychainAccount = "notion-api-token"
+
+	// configWatchInterval は設定ファイルの外部での変更を確認する間隔。
+	configWatchInterval = 2 * time.Second
 )
+
+// Version はビルド時に -ldflags "-X nudge/internal/app.Version=..." で上書きする。
+var Version = "dev"
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"nudge/internal/dto"
+	"nudge/internal/notify"
+	syncer "nudge/internal/sync"
+)
+
+const reminderStateFile = "reminders.json"
+
+func newReminderScheduler(a *App) *syncer.Scheduler {
+	return &syncer.Scheduler{
+		StatePath: a.stateFilePath(reminderStateFile),
+		Fire:      a.fireReminder,
+		Logger:    slog.Default(),
+	}
+}
+
+// markOverdue は now 時点で期限切れのタスクに Overdue を立てる。
+func markOverdue(tasks []dto.Task, now time.Time) []dto.Task {
+	for i := range tasks {
+		tasks[i].Overdue = tasks[i].IsOverdue(now)
+	}
+	return tasks
+}
+
+// scheduleDueReminders はキャッシュ上の期限付きタスクからリマインダーを組み立て直す。
+func (a *App) scheduleDueReminders(now time.Time) {
+	cfg := a.currentConfig()
+	reminders := buildDueReminders(cfg, a.Snapshot(), now)
+	if review, ok := dailyReviewReminder(cfg, now); ok {
+		reminders = append(reminders, review)
+	}
+	a.reminders.Schedule(reminders)
+}
+
+func buildDueReminders(cfg dto.Config, snapshot []dto.DatabaseSnapshot, now time.Time) []syncer.Reminder {
+	rc := cfg.DueReminders
+	var out []syncer.Reminder
+	var digest []string
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, db := range snapshot {
+		if db.Kind != dto.DatabaseKindTask {
+			continue
+		}
+		for _, task := range db.Items {
+			due, hasTime, ok := task.DueTime(now.Location())
+			if !ok {
+				continue
+			}
+			if task.Overdue || due.Before(today.AddDate(0, 0, 1)) {
+				digest = append(digest, digestLine(task, due, hasTime))
+			}
+			if !hasTime {
+				continue
+			}
+			if rc.AtDue {
+				out = append(out, syncer.Reminder{
+					Key:   fmt.Sprintf("due:%s:%s", task.ID, task.Due),
+					Kind:  notify.KindTaskDue,
+					At:    due,
+					Title: db.Name + ": 期限になりました",
+					Body:  task.Title,
+					URL:   task.URL,
+				})
+			}
+			if rc.LeadMinutes > 0 {
+				out = append(out, syncer.Reminder{
+					Key:   fmt.Sprintf("lead:%s:%s:%d", task.ID, task.Due, rc.LeadMinutes),
+					Kind:  notify.KindTaskDue,
+					At:    due.Add(-time.Duration(rc.LeadMinutes) * time.Minute),
+					Title: fmt.Sprintf("%s: 期限の %d 分前です", db.Name, rc.LeadMinutes),
+					Body:  task.Title,
+					URL:   task.URL,
+				})
+			}
+		}
+	}
+	if rc.MorningDigest && len(digest) > 0 {
+		hour, minute, _ := dto.ParseClock(rc.DigestTime)
+		out = append(out, syncer.Reminder{
+			Key:     "digest:" + today.Format("2006-01-02"),
+			Kind:    notify.KindDueDigest,
+			At:      today.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute),
+			Title:   fmt.Sprintf("今日が期限 / 期限切れのタスクが %d 件あります", len(digest)),
+			Body:    strings.Join(digest, "\n"),
+			Expires: today.AddDate(0, 0, 1),
+		})
+	}
+	return out
+}
+
+func digestLine(task dto.Task, due time.Time, hasTime bool) string {
+	mark := "・"
+	if task.Overdue {
+		mark = "⚠ "
+	}
+	if hasTime {
+		return fmt.Sprintf("%s%s（%s）", mark, task.Title, due.Format("01/02 15:04"))
+	}
+	return fmt.Sprintf("%s%s（%s）", mark, task.Title, due.Format("01/02"))
+}
+
+func (a *App) fireReminder(ctx context.Context, r syncer.Reminder) {
+	if r.Kind == notify.KindDailyReview {
+		a.fireDailyReview(ctx)
+		return
+	}
+	a.sendNotification(ctx, notify.Notification{Kind: r.Kind, Title: r.Title, Body: r.Body, URL: r.URL})
+}
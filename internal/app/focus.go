@@ -0,0 +1,367 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"nudge/internal/dto"
+	"nudge/internal/notify"
+)
+
+const (
+	focusStateFile     = "focus.json"
+	focusRecordTimeout = 30 * time.Second
+)
+
+// ErrNoFocusSession は集中セッションが開始されていないことを表す。
+var ErrNoFocusSession = errors.New("focus session is not running")
+
+// StartFocus はキャッシュ上のタスクに対して集中セッションを開始する。
+func (a *App) StartFocus(databaseKey, taskID string) (dto.FocusSession, error) {
+	if taskID == "" {
+		return dto.FocusSession{}, fmt.Errorf("taskID is empty")
+	}
+	db, cfg, err := a.resolveDatabase(databaseKey, dto.DatabaseKindTask)
+	if err != nil {
+		return dto.FocusSession{}, err
+	}
+	if db.Kind != dto.DatabaseKindTask {
+		return dto.FocusSession{}, fmt.Errorf("database kind is not task")
+	}
+	tasks, _ := a.getTaskCache(db.Key)
+	var task *dto.Task
+	for i := range tasks {
+		if tasks[i].ID == taskID {
+			task = &tasks[i]
+			break
+		}
+	}
+	if task == nil {
+		return dto.FocusSession{}, fmt.Errorf("task not found")
+	}
+
+	now := time.Now()
+	a.focusMu.Lock()
+	if a.focus != nil {
+		a.focusMu.Unlock()
+		return dto.FocusSession{}, fmt.Errorf("focus session is already running")
+	}
+	session := dto.FocusSession{
+		DatabaseKey:     db.Key,
+		TaskID:          task.ID,
+		TaskTitle:       task.Title,
+		TaskURL:         task.URL,
+		StartedAt:       now.Format(time.RFC3339),
+		DurationSeconds: cfg.FocusMinutes * 60,
+		ResumedAt:       now.Format(time.RFC3339),
+	}
+	a.focus = &session
+	a.armFocusTimerLocked(now)
+	a.saveFocusLocked()
+	a.focusMu.Unlock()
+
+	a.appendTimeEvent(dto.TimeEventStart, session, now)
+	a.publishFocus()
+	return withRemaining(session, now), nil
+}
+
+// PauseFocus は計測を一時停止する。
+func (a *App) PauseFocus() (dto.FocusSession, error) {
+	now := time.Now()
+	session, err := a.pauseFocus(now)
+	if err != nil {
+		return dto.FocusSession{}, err
+	}
+	a.publishFocus()
+	return withRemaining(session, now), nil
+}
+
+// SuspendFocus はアプリの終了時に計測中のセッションを一時停止して保存する。
+// 停止している間を集中時間や台帳に数えないよう、再起動後は一時停止のまま引き継ぐ。
+func (a *App) SuspendFocus() {
+	if _, err := a.pauseFocus(time.Now()); err != nil && !errors.Is(err, ErrNoFocusSession) {
+		slog.Warn("suspend focus failed", "error", err)
+	}
+}
+
+// pauseFocus は計測中の区間を now で閉じ、一時停止として保存する。一時停止中なら何もしない。
+func (a *App) pauseFocus(now time.Time) (dto.FocusSession, error) {
+	a.focusMu.Lock()
+	if a.focus == nil {
+		a.focusMu.Unlock()
+		return dto.FocusSession{}, ErrNoFocusSession
+	}
+	paused := !a.focus.Paused
+	if paused {
+		a.focus.ElapsedSeconds = int(a.focus.Elapsed(now) / time.Second)
+		a.focus.ResumedAt = ""
+		a.focus.Paused = true
+		a.stopFocusTimerLocked()
+		a.saveFocusLocked()
+	}
+	session := *a.focus
+	a.focusMu.Unlock()
+
+	if paused {
+		a.appendTimeEvent(dto.TimeEventStop, session, now)
+	}
+	return session, nil
+}
+
+// ResumeFocus は一時停止中のセッションを再開する。
+func (a *App) ResumeFocus() (dto.FocusSession, error) {
+	now := time.Now()
+	a.focusMu.Lock()
+	if a.focus == nil {
+		a.focusMu.Unlock()
+		return dto.FocusSession{}, ErrNoFocusSession
+	}
+	resumed := a.focus.Paused
+	if resumed {
+		a.focus.Paused = false
+		a.focus.ResumedAt = now.Format(time.RFC3339)
+		a.armFocusTimerLocked(now)
+		a.saveFocusLocked()
+	}
+	session := *a.focus
+	a.focusMu.Unlock()
+
+	if resumed {
+		a.appendTimeEvent(dto.TimeEventStart, session, now)
+	}
+	a.publishFocus()
+	return withRemaining(session, now), nil
+}
+
+// FinishFocus は予定時間前でもセッションを終了し、経過分を記録する。
+func (a *App) FinishFocus(ctx context.Context) error {
+	session, ok := a.takeFocus()
+	if !ok {
+		return ErrNoFocusSession
+	}
+	now := time.Now()
+	a.stopLedger(session, now)
+	return a.recordFocus(ctx, session, now)
+}
+
+// CancelFocus は記録せずにセッションを破棄する。
+func (a *App) CancelFocus() error {
+	session, ok := a.takeFocus()
+	if !ok {
+		return ErrNoFocusSession
+	}
+	if !session.Paused {
+		a.appendTimeEvent(dto.TimeEventCancel, session, time.Now())
+	}
+	return nil
+}
+
+// CurrentFocus は実行中のセッションを返す。なければ nil。
+func (a *App) CurrentFocus() *dto.FocusSession {
+	a.focusMu.Lock()
+	defer a.focusMu.Unlock()
+	if a.focus == nil {
+		return nil
+	}
+	session := withRemaining(*a.focus, time.Now())
+	return &session
+}
+
+// AddFocusListener はセッションの開始/一時停止/再開/終了時に呼ばれるリスナーを登録する。
+func (a *App) AddFocusListener(fn func(*dto.FocusSession)) {
+	if fn == nil {
+		return
+	}
+	a.focusMu.Lock()
+	defer a.focusMu.Unlock()
+	a.focusListeners = append(a.focusListeners, fn)
+}
+
+// RestoreFocus は前回終了時のセッションを一時停止のまま読み込む。
+// 終了時に一時停止されなかったセッション（異常終了など）は、状態ファイルを最後に保存した時刻で計測中の区間を閉じる。
+func (a *App) RestoreFocus() {
+	path := a.stateFilePath(focusStateFile)
+	if path == "" {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("read focus state failed", "error", err)
+		}
+		return
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		slog.Warn("read focus state failed", "error", err)
+		return
+	}
+	var session dto.FocusSession
+	if err := json.Unmarshal(b, &session); err != nil || session.TaskID == "" {
+		return
+	}
+	a.focusMu.Lock()
+	if a.focus != nil {
+		a.focusMu.Unlock()
+		return
+	}
+	a.focus = &session
+	a.focusMu.Unlock()
+	if !session.Paused {
+		_, _ = a.pauseFocus(info.ModTime())
+	}
+	a.publishFocus()
+}
+
+func (a *App) completeFocus() {
+	// 一時停止と満了のタイマーが競合した場合は一時停止を優先する
+	a.focusMu.Lock()
+	running := a.focus != nil && !a.focus.Paused && a.focus.Remaining(time.Now()) == 0
+	a.focusMu.Unlock()
+	if !running {
+		return
+	}
+	session, ok := a.takeFocus()
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), focusRecordTimeout)
+	defer cancel()
+	now := time.Now()
+	a.stopLedger(session, now)
+	err := a.recordFocus(ctx, session, now)
+	n := notify.Notification{
+		Kind:  notify.KindFocusDone,
+		Title: fmt.Sprintf("集中セッション終了（%d 分）", focusMinutes(session, now)),
+		Body:  session.TaskTitle,
+		URL:   session.TaskURL,
+	}
+	if err != nil {
+		slog.Warn("record focus failed", "task", session.TaskID, "error", err)
+		n.Body += "\n記録に失敗しました: " + err.Error()
+	}
+	a.sendNotification(ctx, n)
+}
+
+// recordFocus は経過分を time_property_name に加算する。未設定ならページ末尾に作業ログを追記する。
+func (a *App) recordFocus(ctx context.Context, session dto.FocusSession, now time.Time) error {
+	minutes := focusMinutes(session, now)
+	if minutes <= 0 {
+		return nil
+	}
+	db, cfg, err := a.resolveDatabase(session.DatabaseKey, dto.DatabaseKindTask)
+	if err != nil {
+		return err
+	}
+	// 自分のタスクへの記録を「他のメンバーの編集」として通知しない
+	a.notifications.markLocalEdit(session.TaskID)
+	if db.TimePropertyName != "" {
+		err = a.notion.ForAccount(db.Account).AddToNumber(ctx, session.TaskID, db.TimePropertyName, float64(minutes), cfg.NotionVersion)
+	} else {
+		started, parseErr := time.Parse(time.RFC3339, session.StartedAt)
+		if parseErr != nil {
+			started = now
+		}
+		entry := fmt.Sprintf("⏱ %s – %s 集中 %d 分", started.Format("2006-01-02 15:04"), now.Format("15:04"), minutes)
+		err = a.notion.ForAccount(db.Account).AppendParagraph(ctx, session.TaskID, entry, cfg.NotionVersion)
+	}
+	if err != nil {
+		return err
+	}
+	return a.syncTimeTotal(ctx, session.TaskID, db, cfg)
+}
+
+// stopLedger は計測中の区間を台帳上で閉じる。スリープなどで満了の処理が遅れた場合は満了時刻で閉じる。
+func (a *App) stopLedger(session dto.FocusSession, now time.Time) {
+	if session.Paused {
+		return
+	}
+	end := now
+	if over := session.Elapsed(now) - time.Duration(session.DurationSeconds)*time.Second; over > 0 {
+		end = now.Add(-over)
+	}
+	a.appendTimeEvent(dto.TimeEventStop, session, end)
+}
+
+// focusMinutes は記録する分数を返す。満了の処理が遅れた場合も予定時間で打ち切る。
+func focusMinutes(session dto.FocusSession, now time.Time) int {
+	elapsed := min(session.Elapsed(now), time.Duration(session.DurationSeconds)*time.Second)
+	return int(math.Round(elapsed.Minutes()))
+}
+
+// takeFocus は実行中のセッションを取り出して状態をクリアする。
+func (a *App) takeFocus() (dto.FocusSession, bool) {
+	a.focusMu.Lock()
+	if a.focus == nil {
+		a.focusMu.Unlock()
+		return dto.FocusSession{}, false
+	}
+	session := *a.focus
+	a.focus = nil
+	a.stopFocusTimerLocked()
+	a.saveFocusLocked()
+	a.focusMu.Unlock()
+
+	a.publishFocus()
+	return session, true
+}
+
+func (a *App) armFocusTimerLocked(now time.Time) {
+	a.stopFocusTimerLocked()
+	a.focusTimer = time.AfterFunc(a.focus.Remaining(now), a.completeFocus)
+}
+
+func (a *App) stopFocusTimerLocked() {
+	if a.focusTimer != nil {
+		a.focusTimer.Stop()
+		a.focusTimer = nil
+	}
+}
+
+// saveFocusLocked はセッションを状態ファイルに保存する。セッションがなければファイルを消す。
+func (a *App) saveFocusLocked() {
+	path := a.stateFilePath(focusStateFile)
+	if path == "" {
+		return
+	}
+	if a.focus == nil {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			slog.Warn("remove focus state failed", "error", err)
+		}
+		return
+	}
+	b, err := json.MarshalIndent(a.focus, "", "  ")
+	if err != nil {
+		slog.Warn("marshal focus state failed", "error", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		slog.Warn("mkdir focus state dir failed", "error", err)
+		return
+	}
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		slog.Warn("write focus state failed", "error", err)
+	}
+}
+
+func (a *App) publishFocus() {
+	session := a.CurrentFocus()
+	a.focusMu.Lock()
+	listeners := append([]func(*dto.FocusSession){}, a.focusListeners...)
+	a.focusMu.Unlock()
+	for _, fn := range listeners {
+		fn(session)
+	}
+}
+
+func withRemaining(session dto.FocusSession, now time.Time) dto.FocusSession {
+	session.RemainingSeconds = int(math.Ceil(session.Remaining(now).Seconds()))
+	return session
+}
@@ -0,0 +1,319 @@
+package app
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"nudge/internal/dto"
+)
+
+const ledgerFile = "timelog.jsonl"
+
+// timeInterval は台帳のイベント列から復元した 1 区間の作業。
+type timeInterval struct {
+	PageID      string
+	DatabaseKey string
+	Title       string
+	Start       time.Time
+	End         time.Time
+}
+
+func (a *App) appendTimeEvent(eventType string, session dto.FocusSession, at time.Time) {
+	ev := dto.TimeEvent{
+		Type:        eventType,
+		PageID:      session.TaskID,
+		DatabaseKey: session.DatabaseKey,
+		Title:       session.TaskTitle,
+		At:          at.Format(time.RFC3339),
+	}
+	if err := a.ledger.Append(ev); err != nil {
+		slog.Warn("append time event failed", "error", err)
+	}
+}
+
+// TimeSummary は period（day / week）で date を含む期間の作業時間を集計する。週は月曜始まり。
+func (a *App) TimeSummary(period string, date time.Time) (dto.TimeSummary, error) {
+	from, to, err := periodRange(period, date)
+	if err != nil {
+		return dto.TimeSummary{}, err
+	}
+	intervals, err := a.timeIntervals(time.Now())
+	if err != nil {
+		return dto.TimeSummary{}, err
+	}
+	return summarize(a.currentConfig(), intervals, from, to), nil
+}
+
+// ExportTimeCSV は [from, to) の作業時間を日付・タスクごとの分数で CSV に書き出す。
+func (a *App) ExportTimeCSV(w io.Writer, from, to time.Time) error {
+	intervals, err := a.timeIntervals(time.Now())
+	if err != nil {
+		return err
+	}
+	cfg := a.currentConfig()
+	type row struct {
+		date, dbKey, pageID, title string
+		seconds                    float64
+	}
+	rows := make(map[string]*row)
+	for _, iv := range intervals {
+		// 日をまたぐ区間は日ごとに分けて計上する
+		for day := startOfDay(iv.Start); day.Before(iv.End); day = day.AddDate(0, 0, 1) {
+			seconds := overlap(iv.Start, iv.End, maxTime(day, from), minTime(day.AddDate(0, 0, 1), to))
+			if seconds <= 0 {
+				continue
+			}
+			key := day.Format("2006-01-02") + "\x00" + iv.PageID
+			r, ok := rows[key]
+			if !ok {
+				r = &row{date: day.Format("2006-01-02"), dbKey: iv.DatabaseKey, pageID: iv.PageID}
+				rows[key] = r
+			}
+			r.title = iv.Title
+			r.seconds += seconds
+		}
+	}
+	sorted := make([]*row, 0, len(rows))
+	for _, r := range rows {
+		sorted = append(sorted, r)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].date != sorted[j].date {
+			return sorted[i].date < sorted[j].date
+		}
+		return sorted[i].title < sorted[j].title
+	})
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"date", "database", "task", "page_id", "minutes"}); err != nil {
+		return err
+	}
+	for _, r := range sorted {
+		minutes := strconv.FormatFloat(r.seconds/60, 'f', 1, 64)
+		if err := cw.Write([]string{r.date, databaseName(cfg, r.dbKey), r.title, r.pageID, minutes}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteTimeCSV は ExportTimeCSV の結果をダウンロードフォルダ（なければ設定ディレクトリ）に保存し、パスを返す。
+func (a *App) WriteTimeCSV(from, to time.Time) (string, error) {
+	dir, err := a.exportDir()
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("nudge-time-%s_%s.csv", from.Format(time.DateOnly), to.AddDate(0, 0, -1).Format(time.DateOnly))
+	path := filepath.Join(dir, name)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return "", err
+	}
+	if err := a.ExportTimeCSV(f, from, to); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// SyncTimeTotals は台帳の累計分数を time_total_property_name を設定した DB のページへ書き込み、更新件数を返す。
+func (a *App) SyncTimeTotals(ctx context.Context) (int, error) {
+	intervals, err := a.timeIntervals(time.Now())
+	if err != nil {
+		return 0, err
+	}
+	cfg := a.currentConfig()
+	updated := 0
+	for pageID, total := range pageTotals(intervals) {
+		db, ok := cfg.DatabaseByKey(total.databaseKey)
+		if !ok || db.TimeTotalPropertyName == "" {
+			continue
+		}
+		a.notifications.markLocalEdit(pageID)
+		if err := a.notion.ForAccount(db.Account).SetNumber(ctx, pageID, db.TimeTotalPropertyName, math.Round(total.minutes), cfg.NotionVersion); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// syncTimeTotal は 1 ページ分の累計を書き込む。DB に time_total_property_name がなければ何もしない。
+func (a *App) syncTimeTotal(ctx context.Context, pageID string, db dto.DatabaseConfig, cfg dto.Config) error {
+	if db.TimeTotalPropertyName == "" {
+		return nil
+	}
+	intervals, err := a.timeIntervals(time.Now())
+	if err != nil {
+		return err
+	}
+	total := pageTotals(intervals)[pageID]
+	a.notifications.markLocalEdit(pageID)
+	return a.notion.ForAccount(db.Account).SetNumber(ctx, pageID, db.TimeTotalPropertyName, math.Round(total.minutes), cfg.NotionVersion)
+}
+
+type pageTotal struct {
+	databaseKey string
+	minutes     float64
+}
+
+func pageTotals(intervals []timeInterval) map[string]pageTotal {
+	out := make(map[string]pageTotal)
+	for _, iv := range intervals {
+		total := out[iv.PageID]
+		total.databaseKey = iv.DatabaseKey
+		total.minutes += iv.End.Sub(iv.Start).Minutes()
+		out[iv.PageID] = total
+	}
+	return out
+}
+
+// timeIntervals は台帳を区間に変換する。終了していない区間は計測中のセッションのものだけ now までを数える。
+func (a *App) timeIntervals(now time.Time) ([]timeInterval, error) {
+	events, err := a.ledger.Events()
+	if err != nil {
+		return nil, err
+	}
+	running := ""
+	if focus := a.CurrentFocus(); focus != nil && !focus.Paused {
+		running = focus.TaskID
+	}
+	open := make(map[string]timeInterval)
+	var out []timeInterval
+	for _, ev := range events {
+		at, err := time.Parse(time.RFC3339, ev.At)
+		if err != nil {
+			continue
+		}
+		at = at.In(now.Location())
+		switch ev.Type {
+		case dto.TimeEventStart:
+			open[ev.PageID] = timeInterval{PageID: ev.PageID, DatabaseKey: ev.DatabaseKey, Title: ev.Title, Start: at}
+		case dto.TimeEventStop:
+			iv, ok := open[ev.PageID]
+			if !ok {
+				continue
+			}
+			delete(open, ev.PageID)
+			if at.After(iv.Start) {
+				iv.End = at
+				out = append(out, iv)
+			}
+		case dto.TimeEventCancel:
+			delete(open, ev.PageID)
+		}
+	}
+	for _, iv := range open {
+		// 終了のない区間（異常終了など）は長さが分からないため数えない
+		if iv.PageID == running && now.After(iv.Start) {
+			iv.End = now
+			out = append(out, iv)
+		}
+	}
+	return out, nil
+}
+
+func summarize(cfg dto.Config, intervals []timeInterval, from, to time.Time) dto.TimeSummary {
+	summary := dto.TimeSummary{From: from.Format(time.RFC3339), To: to.Format(time.RFC3339)}
+	byDB := make(map[string]*dto.DatabaseTime)
+	byTask := make(map[string]*dto.TaskTime)
+	var order []string
+	for _, iv := range intervals {
+		seconds := int(overlap(iv.Start, iv.End, from, to))
+		if seconds <= 0 {
+			continue
+		}
+		db, ok := byDB[iv.DatabaseKey]
+		if !ok {
+			db = &dto.DatabaseTime{Key: iv.DatabaseKey, Name: databaseName(cfg, iv.DatabaseKey)}
+			byDB[iv.DatabaseKey] = db
+			order = append(order, iv.DatabaseKey)
+		}
+		task, ok := byTask[iv.PageID]
+		if !ok {
+			task = &dto.TaskTime{PageID: iv.PageID}
+			byTask[iv.PageID] = task
+		}
+		task.Title = iv.Title
+		task.Seconds += seconds
+		db.Seconds += seconds
+		summary.TotalSeconds += seconds
+	}
+	for _, iv := range intervals {
+		task, ok := byTask[iv.PageID]
+		if !ok {
+			continue
+		}
+		delete(byTask, iv.PageID)
+		db := byDB[iv.DatabaseKey]
+		db.Tasks = append(db.Tasks, *task)
+	}
+	for _, key := range order {
+		db := byDB[key]
+		sort.SliceStable(db.Tasks, func(i, j int) bool { return db.Tasks[i].Seconds > db.Tasks[j].Seconds })
+		summary.Databases = append(summary.Databases, *db)
+	}
+	return summary
+}
+
+func periodRange(period string, date time.Time) (time.Time, time.Time, error) {
+	day := startOfDay(date)
+	switch period {
+	case dto.TimePeriodDay, "":
+		return day, day.AddDate(0, 0, 1), nil
+	case dto.TimePeriodWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		from := day.AddDate(0, 0, -offset)
+		return from, from.AddDate(0, 0, 7), nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown period: %s", period)
+	}
+}
+
+func databaseName(cfg dto.Config, key string) string {
+	if db, ok := cfg.DatabaseByKey(key); ok {
+		return db.Name
+	}
+	return key
+}
+
+// overlap は [start, end) と [from, to) の重なりを秒で返す。
+func overlap(start, end, from, to time.Time) float64 {
+	s := maxTime(start, from)
+	e := minTime(end, to)
+	if !e.After(s) {
+		return 0
+	}
+	return e.Sub(s).Seconds()
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,268 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"nudge/internal/dto"
+	"nudge/internal/notify"
+	"nudge/internal/notion/notionid"
+)
+
+const (
+	// localEditTTL の間は Nudge 自身が行った更新を「他人の編集」として扱わない。
+	localEditTTL      = 5 * time.Minute
+	notifyTimeout     = 10 * time.Second
+	maxReminderTitles = 3
+)
+
+// notificationTracker は更新ごとのキャッシュ差分から通知を組み立てる。
+type notificationTracker struct {
+	mu         sync.Mutex
+	notifier   notify.Notifier
+	tasks      map[string]map[string]dto.Task // db key -> page id -> task
+	failures   int
+	lastCheck  time.Time
+	localEdits map[string]time.Time
+	// bots はアカウント ID ごとのトークンのボットユーザー ID。Nudge の更新（別プロセスの nudge mcp を含む）はこのユーザーの編集になる
+	bots map[string]string
+}
+
+func newNotificationTracker() *notificationTracker {
+	return &notificationTracker{
+		tasks:      make(map[string]map[string]dto.Task),
+		localEdits: make(map[string]time.Time),
+		bots:       make(map[string]string),
+	}
+}
+
+// SetNotifier はデスクトップ通知の送信先を設定する。nil の場合は通知しない。
+func (a *App) SetNotifier(n notify.Notifier) {
+	a.notifications.mu.Lock()
+	defer a.notifications.mu.Unlock()
+	a.notifications.notifier = n
+}
+
+// markLocalEdit は Nudge 自身が更新したページを記録する。ID の書式（ハイフンの有無や URL）は問わない。
+func (t *notificationTracker) markLocalEdit(pageID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.localEdits[notionid.Normalize(pageID)] = time.Now()
+}
+
+// setBot は account のトークンのボットユーザー ID を記録する。空なら記録を消す。
+func (t *notificationTracker) setBot(account, botID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if botID == "" {
+		delete(t.bots, account)
+		return
+	}
+	t.bots[account] = normalizeUserID(botID)
+}
+
+// editedByBot は editor が Nudge のトークンのボットユーザーかを返す。t.mu を持った状態で呼ぶ。
+func (t *notificationTracker) editedByBot(editor string) bool {
+	editor = normalizeUserID(editor)
+	for _, bot := range t.bots {
+		if bot == editor {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateNotifications は refreshAll の結果と現在のキャッシュを前回と比較して通知を送る。
+func (a *App) evaluateNotifications(ctx context.Context, refreshErr error) {
+	cfg := a.currentConfig()
+	for _, n := range a.notifications.collect(cfg.Notifications, a.Snapshot(), refreshErr, time.Now()) {
+		a.sendNotification(ctx, n)
+	}
+}
+
+// NotifyActionFailed はトレイなど結果を表示する画面がない操作の失敗を、ログに残してデスクトップ通知で知らせる。
+func (a *App) NotifyActionFailed(title string, err error) {
+	slog.Warn("action failed", "title", title, "error", err)
+	a.sendNotification(context.Background(), notify.Notification{
+		Kind:  notify.KindActionFailed,
+		Title: title,
+		Body:  err.Error(),
+	})
+}
+
+// sendNotification は設定済みの Notifier に通知を送る。失敗はログに残すだけにする。
+func (a *App) sendNotification(ctx context.Context, n notify.Notification) {
+	a.notifications.mu.Lock()
+	notifier := a.notifications.notifier
+	a.notifications.mu.Unlock()
+	if notifier == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	if err := notifier.Notify(ctx, n); err != nil {
+		slog.Warn("notification failed", "kind", n.Kind, "error", err)
+	}
+}
+
+func (t *notificationTracker) collect(cfg dto.NotificationConfig, snapshot []dto.DatabaseSnapshot, refreshErr error, now time.Time) []notify.Notification {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for id, at := range t.localEdits {
+		if now.Sub(at) > localEditTTL {
+			delete(t.localEdits, id)
+		}
+	}
+
+	var out []notify.Notification
+	if refreshErr != nil {
+		t.failures++
+		// 閾値に達した回だけ通知し、復旧するまで繰り返さない
+		if cfg.SyncFailure && t.failures == cfg.SyncFailureThreshold {
+			out = append(out, notify.Notification{
+				Kind:  notify.KindSyncFailure,
+				Title: "Notion と同期できません",
+				Body:  fmt.Sprintf("%d 回連続で失敗しました: %v", t.failures, refreshErr),
+			})
+		}
+	} else {
+		t.failures = 0
+	}
+
+	var unchecked []dto.Task
+	for _, db := range snapshot {
+		switch db.Kind {
+		case dto.DatabaseKindHabit:
+			unchecked = append(unchecked, db.Items...)
+		default:
+			out = append(out, t.diffTasks(cfg, db)...)
+		}
+	}
+
+	if cfg.HabitReminder && !t.lastCheck.IsZero() && len(unchecked) > 0 && reminderDue(cfg.HabitReminderTimes, t.lastCheck, now) {
+		out = append(out, notify.Notification{
+			Kind:  notify.KindHabitReminder,
+			Title: fmt.Sprintf("未チェックの習慣が %d 件あります", len(unchecked)),
+			Body:  habitSummary(unchecked),
+		})
+	}
+	t.lastCheck = now
+	return out
+}
+
+// diffTasks は前回の内容と比較して、新しく進行中になったタスク、他人による自分への割り当て、他人の編集を通知にする。
+// 初回（前回の内容がない DB）は基準を記録するだけで通知しない。
+func (t *notificationTracker) diffTasks(cfg dto.NotificationConfig, db dto.DatabaseSnapshot) []notify.Notification {
+	prev, primed := t.tasks[db.Key]
+	current := make(map[string]dto.Task, len(db.Items))
+	for _, task := range db.Items {
+		current[task.ID] = task
+	}
+	t.tasks[db.Key] = current
+	if !primed {
+		return nil
+	}
+
+	var out []notify.Notification
+	for _, task := range db.Items {
+		if _, ok := t.localEdits[notionid.Normalize(task.ID)]; ok {
+			continue
+		}
+		before, existed := prev[task.ID]
+		switch {
+		case !existed:
+			if cfg.TaskStarted {
+				out = append(out, notify.Notification{
+					Kind:  notify.KindTaskStarted,
+					Title: db.Name + ": 進行中になりました",
+					Body:  task.Title,
+					URL:   task.URL,
+				})
+			}
+		case before.LastEditedTime != task.LastEditedTime:
+			if !editedByOther(cfg.NotionUserID, task.LastEditedBy) || t.editedByBot(task.LastEditedBy) {
+				continue
+			}
+			// 割り当ての通知を送った編集は、重ねて「更新しました」を送らない
+			if cfg.TaskAssigned && !assignedTo(cfg.NotionUserID, before.Assignees) && assignedTo(cfg.NotionUserID, task.Assignees) {
+				out = append(out, notify.Notification{
+					Kind:  notify.KindTaskAssigned,
+					Title: db.Name + ": 担当に割り当てられました",
+					Body:  task.Title,
+					URL:   task.URL,
+				})
+				continue
+			}
+			if cfg.TaskEdited {
+				out = append(out, notify.Notification{
+					Kind:  notify.KindTaskEdited,
+					Title: db.Name + ": 他のメンバーが更新しました",
+					Body:  task.Title,
+					URL:   task.URL,
+				})
+			}
+		}
+	}
+	return out
+}
+
+// editedByOther は自分の Notion ユーザー ID が設定されている場合のみ判定できる。
+func editedByOther(self, editor string) bool {
+	if self == "" || editor == "" {
+		return false
+	}
+	return normalizeUserID(self) != normalizeUserID(editor)
+}
+
+// assignedTo は assignees に自分の Notion ユーザー ID が含まれるかを返す。
+func assignedTo(self string, assignees []string) bool {
+	if self == "" {
+		return false
+	}
+	self = normalizeUserID(self)
+	for _, id := range assignees {
+		if normalizeUserID(id) == self {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeUserID(id string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(id), "-", ""))
+}
+
+// reminderDue は (since, now] の間に設定時刻を跨いだかを返す。
+func reminderDue(times []string, since, now time.Time) bool {
+	for _, value := range times {
+		hour, minute, ok := dto.ParseClock(value)
+		if !ok {
+			continue
+		}
+		at := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+		if at.After(since) && !at.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func habitSummary(habits []dto.Task) string {
+	titles := make([]string, 0, maxReminderTitles)
+	for _, habit := range habits {
+		if len(titles) == maxReminderTitles {
+			break
+		}
+		titles = append(titles, habit.Title)
+	}
+	summary := strings.Join(titles, "、")
+	if len(habits) > maxReminderTitles {
+		summary += fmt.Sprintf(" ほか %d 件", len(habits)-maxReminderTitles)
+	}
+	return summary
+}
@@ -0,0 +1,213 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"nudge/internal/dto"
+	"nudge/internal/notify"
+	"nudge/internal/store"
+)
+
+const testUserID = "11111111-2222-3333-4444-555555555555"
+
+// newNotificationTestApp は tasks を 1 つだけ持つ設定の App と、通知を記録する LogNotifier を返す。
+func newNotificationTestApp(t *testing.T) (*App, *notify.LogNotifier) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+	a := NewApp(store.NewFileConfigStore("nudge-test"), nil, nil)
+	cfg := dto.DefaultConfig()
+	cfg.Databases = []dto.DatabaseConfig{{Key: "tasks", Name: "タスク", Kind: dto.DatabaseKindTask, Enabled: true}}
+	cfg.Notifications.TaskStarted = true
+	cfg.Notifications.TaskEdited = true
+	cfg.Notifications.HabitReminder = false
+	cfg.Notifications.NotionUserID = testUserID
+	a.cfg = cfg.Normalize()
+
+	notifier := &notify.LogNotifier{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	a.SetNotifier(notifier)
+	return a, notifier
+}
+
+// poll は 1 回分の更新結果をキャッシュに入れて通知を評価し、新しく送られた通知を返す。
+func poll(a *App, notifier *notify.LogNotifier, tasks ...dto.Task) []notify.Notification {
+	before := len(notifier.Sent())
+	a.cacheMu.Lock()
+	a.taskCache["tasks"] = tasks
+	a.cacheMu.Unlock()
+	a.evaluateNotifications(context.Background(), nil)
+	return notifier.Sent()[before:]
+}
+
+func assertNotified(t *testing.T, got []notify.Notification, kind, body string) {
+	t.Helper()
+	if len(got) != 1 {
+		t.Fatalf("notifications = %+v, want one %s", got, kind)
+	}
+	if got[0].Kind != kind || got[0].Body != body {
+		t.Errorf("notification = %+v, want %s for %q", got[0], kind, body)
+	}
+}
+
+func TestNotificationsFirstPollOnlyRecordsBaseline(t *testing.T) {
+	a, notifier := newNotificationTestApp(t)
+	got := poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", Status: "進行中", LastEditedTime: "2026-10-01T09:00:00Z"})
+	if len(got) != 0 {
+		t.Fatalf("notifications = %+v, want none on the first poll", got)
+	}
+}
+
+func TestNotificationsNewTask(t *testing.T) {
+	a, notifier := newNotificationTestApp(t)
+	poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:00:00Z"})
+
+	got := poll(a, notifier,
+		dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:00:00Z"},
+		dto.Task{ID: "p2", Title: "レビュー", URL: "https://www.notion.so/p2", LastEditedTime: "2026-10-01T09:05:00Z"},
+	)
+	assertNotified(t, got, notify.KindTaskStarted, "レビュー")
+	if got[0].URL != "https://www.notion.so/p2" {
+		t.Errorf("url = %q", got[0].URL)
+	}
+}
+
+func TestNotificationsStatusChange(t *testing.T) {
+	a, notifier := newNotificationTestApp(t)
+	poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", Status: "進行中", LastEditedTime: "2026-10-01T09:00:00Z"})
+
+	// 進行中でなくなったタスクは一覧から消えるだけで通知しない
+	if got := poll(a, notifier); len(got) != 0 {
+		t.Fatalf("notifications = %+v, want none when a task leaves", got)
+	}
+	// 進行中に戻ったタスクは新しく進行中になったものとして通知する
+	got := poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", Status: "進行中", LastEditedTime: "2026-10-01T10:00:00Z"})
+	assertNotified(t, got, notify.KindTaskStarted, "資料作成")
+}
+
+func TestNotificationsEditedByOthers(t *testing.T) {
+	a, notifier := newNotificationTestApp(t)
+	poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:00:00Z", LastEditedBy: testUserID})
+
+	// 自分の編集（ハイフンの有無は問わない）は通知しない
+	self := "11111111222233334444555555555555"
+	if got := poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:10:00Z", LastEditedBy: self}); len(got) != 0 {
+		t.Fatalf("notifications = %+v, want none for my own edit", got)
+	}
+
+	got := poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:20:00Z", LastEditedBy: "other-user"})
+	assertNotified(t, got, notify.KindTaskEdited, "資料作成")
+
+	// Nudge 自身の更新は他人の編集として扱わない
+	a.notifications.markLocalEdit("p1")
+	if got := poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:30:00Z", LastEditedBy: "other-user"}); len(got) != 0 {
+		t.Fatalf("notifications = %+v, want none for a local edit", got)
+	}
+}
+
+func TestNotificationsIgnoresBotEdits(t *testing.T) {
+	a, notifier := newNotificationTestApp(t)
+	const bot = "99999999-8888-7777-6666-555555555555"
+	a.notifications.setBot("", bot)
+	poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:00:00Z", LastEditedBy: testUserID})
+
+	// 別プロセス（nudge mcp など）がトークンで更新したページはボットユーザーの編集になる
+	if got := poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:10:00Z", LastEditedBy: "99999999888877776666555555555555"}); len(got) != 0 {
+		t.Fatalf("notifications = %+v, want none for an edit by the integration", got)
+	}
+
+	a.notifications.setBot("", "")
+	got := poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:20:00Z", LastEditedBy: bot})
+	assertNotified(t, got, notify.KindTaskEdited, "資料作成")
+}
+
+func TestNotificationsAssigned(t *testing.T) {
+	a, notifier := newNotificationTestApp(t)
+	cfg := a.currentConfig()
+	cfg.Notifications.TaskAssigned = true
+	a.cfg = cfg
+	poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:00:00Z", Assignees: []string{"other-user"}})
+
+	// 他人が自分を担当者に加えた編集は、割り当てとしてだけ通知する
+	got := poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:10:00Z", LastEditedBy: "other-user", Assignees: []string{"other-user", testUserID}})
+	assertNotified(t, got, notify.KindTaskAssigned, "資料作成")
+
+	// 既に担当者のタスクの編集は通常の編集として通知する
+	got = poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:20:00Z", LastEditedBy: "other-user", Assignees: []string{testUserID}})
+	assertNotified(t, got, notify.KindTaskEdited, "資料作成")
+
+	// 自分で自分を担当者にした場合は通知しない
+	poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:30:00Z", LastEditedBy: testUserID})
+	if got := poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:40:00Z", LastEditedBy: testUserID, Assignees: []string{testUserID}}); len(got) != 0 {
+		t.Fatalf("notifications = %+v, want none when I assign myself", got)
+	}
+}
+
+func TestNotificationsSyncFailureThreshold(t *testing.T) {
+	tracker := newNotificationTracker()
+	cfg := dto.NotificationConfig{SyncFailure: true, SyncFailureThreshold: 2}
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	failed := errors.New("notion unavailable")
+
+	counts := make([]int, 0, 6)
+	for _, err := range []error{failed, failed, failed, nil, failed, failed} {
+		counts = append(counts, len(tracker.collect(cfg, nil, err, now)))
+		now = now.Add(time.Minute)
+	}
+	// 閾値に達した回だけ通知し、成功すると数え直す
+	want := []int{0, 1, 0, 0, 0, 1}
+	for i := range want {
+		if counts[i] != want[i] {
+			t.Fatalf("notifications per poll = %v, want %v", counts, want)
+		}
+	}
+}
+
+func TestReminderDue(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 1, hour, minute, 0, 0, time.Local)
+	}
+	times := []string{"12:00", "21:00"}
+	tests := []struct {
+		name       string
+		since, now time.Time
+		want       bool
+	}{
+		{"before", at(11, 58), at(11, 59), false},
+		{"crossing", at(11, 59), at(12, 0), true},
+		{"crossing with a gap", at(20, 30), at(21, 15), true},
+		{"already passed", at(12, 0), at(12, 1), false},
+		{"between", at(13, 0), at(14, 0), false},
+	}
+	for _, tt := range tests {
+		if got := reminderDue(times, tt.since, tt.now); got != tt.want {
+			t.Errorf("%s: reminderDue(%v, %v) = %v, want %v", tt.name, tt.since, tt.now, got, tt.want)
+		}
+	}
+	if reminderDue([]string{"invalid"}, at(0, 0), at(23, 59)) {
+		t.Error("reminderDue with an invalid time = true, want false")
+	}
+}
+
+func TestNotificationsDisabled(t *testing.T) {
+	a, notifier := newNotificationTestApp(t)
+	cfg := a.currentConfig()
+	cfg.Notifications.TaskStarted = false
+	cfg.Notifications.TaskEdited = false
+	a.cfg = cfg
+	poll(a, notifier, dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:00:00Z"})
+
+	got := poll(a, notifier,
+		dto.Task{ID: "p1", Title: "資料作成", LastEditedTime: "2026-10-01T09:20:00Z", LastEditedBy: "other-user"},
+		dto.Task{ID: "p2", Title: "レビュー", LastEditedTime: "2026-10-01T09:05:00Z"},
+	)
+	if len(got) != 0 {
+		t.Fatalf("notifications = %+v, want none when disabled", got)
+	}
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"nudge/internal/dto"
+	"nudge/internal/oauth"
+	"nudge/internal/store"
+)
+
+const (
+	// oauthClientSecretAccount は OAuth のクライアントシークレットを保存する TokenStore のアカウント ID。
+	// 利用者のアカウント ID と重ならないよう、予約された接頭辞を付ける。
+	oauthClientSecretAccount = dto.ReservedTokenAccountPrefix + "oauth-client-secret"
+	workspacesFile           = "workspaces.json"
+)
+
+// ConnectNotion は OAuth の認可コードフローで Notion と接続し、アクセストークンとワークスペースの情報を account に保存する。
+// open で認可画面をブラウザに開き、リダイレクトを待つ間は ctx が切れるまで待つ。
+func (a *App) ConnectNotion(ctx context.Context, account string, open func(url string) error) (dto.NotionWorkspace, error) {
+	if err := dto.ValidateTokenAccount(account); err != nil {
+		return dto.NotionWorkspace{}, err
+	}
+	cfg := a.currentConfig().OAuth
+	if cfg.ClientID == "" {
+		return dto.NotionWorkspace{}, fmt.Errorf("oauth client_id is not configured")
+	}
+	secret, err := a.tokenStoreFor(oauthClientSecretAccount).GetToken()
+	if err != nil && !errors.Is(err, store.ErrTokenNotFound) {
+		return dto.NotionWorkspace{}, fmt.Errorf("read oauth client secret: %w", err)
+	}
+	flow := &oauth.Flow{
+		Config: oauth.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: secret,
+			AuthorizeURL: cfg.AuthorizeURL,
+			TokenURL:     cfg.TokenURL,
+			RedirectPort: cfg.RedirectPort,
+		},
+		OpenBrowser: open,
+	}
+	token, err := flow.Run(ctx)
+	if err != nil {
+		return dto.NotionWorkspace{}, err
+	}
+	if err := a.tokenStoreFor(account).SetToken(token.AccessToken); err != nil {
+		return dto.NotionWorkspace{}, err
+	}
+	workspace := dto.NotionWorkspace{
+		ID:    token.WorkspaceID,
+		Name:  token.WorkspaceName,
+		Icon:  token.WorkspaceIcon,
+		BotID: token.BotID,
+	}
+	a.saveWorkspace(account, workspace)
+	a.resumeAfterTokenChange(account)
+	return workspace, nil
+}
+
+// SetOAuthClientSecret は OAuth のクライアントシークレットを保存する。空なら削除する。
+func (a *App) SetOAuthClientSecret(secret string) error {
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return a.tokenStoreFor(oauthClientSecretAccount).ClearToken()
+	}
+	return a.tokenStoreFor(oauthClientSecretAccount).SetToken(secret)
+}
+
+// saveWorkspace は account のワークスペースの情報を保存する。表示用の情報のため、失敗しても記録に留める。
+func (a *App) saveWorkspace(account string, workspace dto.NotionWorkspace) {
+	account = tokenAccount(a.currentConfig(), account)
+	a.notifications.setBot(account, workspace.BotID)
+	if err := a.workspaces.Save(account, workspace); err != nil {
+		slog.Warn("save notion workspace failed", "error", err)
+	}
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"nudge/internal/dto"
+)
+
+// SwitchProfile は設定プロファイルを切り替えて保存し、トークンとポーリングを新しいプロファイルに合わせる。
+// 取り込みと同じく検証するのは切り替え先のプロファイルだけで、他の項目の誤りでは切り替えを止めない。
+func (a *App) SwitchProfile(name string) error {
+	prev := a.currentConfig()
+	if prev.ActiveProfile == name {
+		return nil
+	}
+	next, err := prev.SwitchProfile(name)
+	if err != nil {
+		return err
+	}
+	if errs := prev.ValidateProfile(name); len(errs) > 0 {
+		return errs
+	}
+	if _, err := a.saveConfig(next); err != nil {
+		return err
+	}
+	a.applyConfig(prev, a.currentConfig())
+	return nil
+}
+
+// WriteProfileBundle はプロファイルをトークンを含まない JSON に書き出し、保存先のパスを返す。
+func (a *App) WriteProfileBundle(name string) (string, error) {
+	bundle, err := a.currentConfig().ExportProfile(name)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal profile bundle: %w", err)
+	}
+	dir, err := a.exportDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("nudge-profile-%s.json", safeFileName(bundle.Name)))
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		return "", fmt.Errorf("write profile bundle: %w", err)
+	}
+	return path, nil
+}
+
+// ImportProfile は書き出されたプロファイルを取り込んで保存し、プロファイル名を返す。
+// 有効なプロファイルは切り替えない（雛形の DB しかなければ取り込んだものを有効にする）。
+// 検証するのは取り込むプロファイルだけで、既存の設定の誤りでは取り込みを止めない。
+func (a *App) ImportProfile(bundle dto.ProfileBundle) (string, error) {
+	if errs := bundle.Validate(); len(errs) > 0 {
+		return "", errs
+	}
+	prev := a.currentConfig()
+	next, err := prev.ImportProfile(bundle)
+	if err != nil {
+		return "", err
+	}
+	if _, err := a.saveConfig(next); err != nil {
+		return "", err
+	}
+	a.applyConfig(prev, a.currentConfig())
+	return strings.TrimSpace(bundle.Name), nil
+}
+
+// safeFileName はファイル名に使えない文字を "_" に置き換える。
+func safeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		if r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+}
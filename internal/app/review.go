@@ -0,0 +1,229 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"nudge/internal/dto"
+	"nudge/internal/notify"
+	"nudge/internal/notion"
+	syncer "nudge/internal/sync"
+)
+
+const (
+	historyFile          = "history.jsonl"
+	dailyReviewTimeout   = 2 * time.Minute
+	dailyReviewKeyPrefix = "review:"
+)
+
+// dailyReview は日次レビューに載せる内容。
+type dailyReview struct {
+	Done         []dto.ActionRecord
+	InProgress   []dto.Task
+	HabitsDone   []dto.ActionRecord
+	HabitsMissed []dto.Task
+	FocusTotal   int
+	FocusByTask  []dto.TaskTime
+}
+
+// CreateDailyReview は今日 Nudge から完了したタスク・進行中のタスク・習慣の達成状況をまとめたページを Brain DB に作成する。
+func (a *App) CreateDailyReview(ctx context.Context) (dto.CreatedPage, error) {
+	profile, cfg, err := a.resolveBrainProfile(a.currentConfig().DailyReview.Profile)
+	if err != nil {
+		return dto.CreatedPage{}, err
+	}
+	// 進行中・未チェックの一覧はできるだけ最新にする。失敗してもキャッシュで作成する
+	if err := a.refreshAll(ctx); err != nil {
+		slog.Warn("refresh before daily review failed", "error", err)
+	}
+	now := time.Now()
+	review, err := a.collectDailyReview(now)
+	if err != nil {
+		return dto.CreatedPage{}, err
+	}
+	opts := notion.PageOptions{
+		Title:      fmt.Sprintf("%s %s", cfg.DailyReview.Title, now.Format(time.DateOnly)),
+		Properties: profile.Properties,
+	}
+	return a.notion.ForAccount(profile.Account).CreatePageWithBlocks(ctx, profile.DatabaseID, profile.TemplatePageID, opts, review.blocks(), cfg.NotionVersion)
+}
+
+// recordAction は Nudge からの操作を履歴に残す。タイトルはキャッシュから補う。
+func (a *App) recordAction(action string, db dto.DatabaseConfig, pageID string) {
+	rec := dto.ActionRecord{
+		Action:      action,
+		DatabaseKey: db.Key,
+		PageID:      pageID,
+		At:          time.Now().Format(time.RFC3339),
+	}
+	items, _ := a.getTaskCache(db.Key)
+	if db.Kind == dto.DatabaseKindHabit {
+		items, _ = a.getHabitCache(db.Key)
+	}
+	for _, item := range items {
+		if item.ID == pageID {
+			rec.Title = item.Title
+			rec.URL = item.URL
+			break
+		}
+	}
+	if err := a.history.Append(rec); err != nil {
+		slog.Warn("append action history failed", "error", err)
+	}
+}
+
+func taskAction(action string) string {
+	switch action {
+	case "done":
+		return dto.ActionTaskDone
+	case "paused":
+		return dto.ActionTaskPaused
+	default:
+		return dto.ActionTaskResumed
+	}
+}
+
+func (a *App) collectDailyReview(now time.Time) (dailyReview, error) {
+	records, err := a.history.Records()
+	if err != nil {
+		return dailyReview{}, err
+	}
+	from := startOfDay(now)
+	to := from.AddDate(0, 0, 1)
+
+	// ページごとに今日の最後の操作だけを見る（完了→再開などは取り消し扱い）
+	latest := make(map[string]dto.ActionRecord)
+	var order []string
+	for _, rec := range records {
+		at, err := time.Parse(time.RFC3339, rec.At)
+		if err != nil || at.Before(from) || !at.Before(to) {
+			continue
+		}
+		if _, ok := latest[rec.PageID]; !ok {
+			order = append(order, rec.PageID)
+		}
+		prev := latest[rec.PageID]
+		if rec.Title == "" {
+			rec.Title = prev.Title
+		}
+		latest[rec.PageID] = rec
+	}
+
+	var review dailyReview
+	for _, id := range order {
+		rec := latest[id]
+		switch rec.Action {
+		case dto.ActionTaskDone:
+			review.Done = append(review.Done, rec)
+		case dto.ActionHabitChecked:
+			review.HabitsDone = append(review.HabitsDone, rec)
+		}
+	}
+	for _, db := range a.Snapshot() {
+		for _, item := range db.Items {
+			if db.Kind != dto.DatabaseKindHabit {
+				review.InProgress = append(review.InProgress, item)
+				continue
+			}
+			if rec, ok := latest[item.ID]; ok && rec.Action == dto.ActionHabitChecked {
+				continue
+			}
+			review.HabitsMissed = append(review.HabitsMissed, item)
+		}
+	}
+
+	intervals, err := a.timeIntervals(now)
+	if err != nil {
+		return dailyReview{}, err
+	}
+	summary := summarize(a.currentConfig(), intervals, from, to)
+	review.FocusTotal = summary.TotalSeconds
+	for _, db := range summary.Databases {
+		review.FocusByTask = append(review.FocusByTask, db.Tasks...)
+	}
+	return review, nil
+}
+
+func (r dailyReview) blocks() []notion.Block {
+	var out []notion.Block
+	section := func(heading string, count int) {
+		out = append(out, notion.Heading(2, fmt.Sprintf("%s（%d）", heading, count)))
+		if count == 0 {
+			out = append(out, notion.Paragraph("なし"))
+		}
+	}
+
+	section("完了したタスク", len(r.Done))
+	for _, rec := range r.Done {
+		out = append(out, notion.ToDo(recordTitle(rec), true))
+	}
+	section("進行中のタスク", len(r.InProgress))
+	for _, task := range r.InProgress {
+		line := task.Title
+		if task.Overdue {
+			line = "⚠ " + line
+		}
+		out = append(out, notion.BulletedItem(line))
+	}
+	section("習慣", len(r.HabitsDone)+len(r.HabitsMissed))
+	for _, rec := range r.HabitsDone {
+		out = append(out, notion.ToDo(recordTitle(rec), true))
+	}
+	for _, habit := range r.HabitsMissed {
+		out = append(out, notion.ToDo(habit.Title, false))
+	}
+	if r.FocusTotal > 0 {
+		out = append(out, notion.Heading(2, fmt.Sprintf("集中時間（%d 分）", r.FocusTotal/60)))
+		for _, task := range r.FocusByTask {
+			out = append(out, notion.BulletedItem(fmt.Sprintf("%s – %d 分", task.Title, task.Seconds/60)))
+		}
+	}
+	return out
+}
+
+func recordTitle(rec dto.ActionRecord) string {
+	if rec.Title != "" {
+		return rec.Title
+	}
+	return rec.PageID
+}
+
+// dailyReviewReminder は設定時刻に日次レビューを作成する予定を返す。無効または作成先のプロファイルがなければ ok=false。
+func dailyReviewReminder(cfg dto.Config, now time.Time) (syncer.Reminder, bool) {
+	if !cfg.DailyReview.Enabled {
+		return syncer.Reminder{}, false
+	}
+	if _, ok := cfg.BrainProfileByKey(cfg.DailyReview.Profile); !ok {
+		return syncer.Reminder{}, false
+	}
+	hour, minute, _ := dto.ParseClock(cfg.DailyReview.Time)
+	today := startOfDay(now)
+	return syncer.Reminder{
+		Key:     dailyReviewKeyPrefix + today.Format(time.DateOnly),
+		Kind:    notify.KindDailyReview,
+		At:      today.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute),
+		Expires: today.AddDate(0, 0, 1),
+	}, true
+}
+
+func (a *App) fireDailyReview(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, dailyReviewTimeout)
+	defer cancel()
+	page, err := a.CreateDailyReview(ctx)
+	if err != nil {
+		slog.Warn("create daily review failed", "error", err)
+		a.sendNotification(ctx, notify.Notification{
+			Kind:  notify.KindDailyReview,
+			Title: "日次レビューを作成できませんでした",
+			Body:  err.Error(),
+		})
+		return
+	}
+	a.sendNotification(ctx, notify.Notification{
+		Kind:  notify.KindDailyReview,
+		Title: "日次レビューを作成しました",
+		URL:   page.URL,
+	})
+}
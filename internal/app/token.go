@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"nudge/internal/dto"
+	"nudge/internal/notion"
+	"nudge/internal/store"
+)
+
+// GetToken は account のトークンを返す。account が空なら有効なプロファイルのトークン。
+func (a *App) GetToken(account string) (string, error) {
+	if err := dto.ValidateTokenAccount(account); err != nil {
+		return "", err
+	}
+	return a.tokenStoreFor(account).GetToken()
+}
+
+// SetToken はトークンを Notion に確認してから account に保存し、インテグレーションの情報を返す。
+// 無効なトークンで取得を止めていた account の DB は取得を再開する。
+func (a *App) SetToken(ctx context.Context, account, token string) (dto.TokenIdentity, error) {
+	if err := dto.ValidateTokenAccount(account); err != nil {
+		return dto.TokenIdentity{}, err
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return dto.TokenIdentity{}, fmt.Errorf("token is empty")
+	}
+	identity, err := a.notion.VerifyToken(ctx, token, a.currentConfig().NotionVersion)
+	if err != nil {
+		return dto.TokenIdentity{}, fmt.Errorf("verify token: %w", err)
+	}
+	if err := a.tokenStoreFor(account).SetToken(token); err != nil {
+		return dto.TokenIdentity{}, err
+	}
+	a.saveWorkspace(account, dto.NotionWorkspace{Name: identity.WorkspaceName, BotID: identity.ID})
+	a.resumeAfterTokenChange(account)
+	return identity, nil
+}
+
+// ClearToken は account のトークンと、保存したワークスペースの情報を削除する。
+func (a *App) ClearToken(account string) error {
+	if err := dto.ValidateTokenAccount(account); err != nil {
+		return err
+	}
+	if err := a.tokenStoreFor(account).ClearToken(); err != nil {
+		return err
+	}
+	account = tokenAccount(a.currentConfig(), account)
+	a.notifications.setBot(account, "")
+	return a.workspaces.Remove(account)
+}
+
+// VerifyToken は account に保存済みのトークンを Notion に確認し、インテグレーションの情報を返す。
+func (a *App) VerifyToken(ctx context.Context, account string) (dto.TokenIdentity, error) {
+	if err := dto.ValidateTokenAccount(account); err != nil {
+		return dto.TokenIdentity{}, err
+	}
+	return a.notion.ForAccount(strings.TrimSpace(account)).Me(ctx, a.currentConfig().NotionVersion)
+}
+
+// CheckToken は起動時に、有効な DB と Brain プロファイルが使うトークンをアカウントごとに確認する。
+// 拒否されたアカウントは DB の取得を止めて状態を通知する。確認できたボットユーザーの編集は、通知では自分の編集として扱う。
+func (a *App) CheckToken(ctx context.Context) {
+	var rejected error
+	for _, account := range usedTokenAccounts(a.currentConfig()) {
+		identity, err := a.VerifyToken(ctx, account)
+		switch {
+		case err == nil:
+			a.notifications.setBot(account, identity.ID)
+			slog.Info("notion token verified", "account", account, "integration", identity.Name, "workspace", identity.WorkspaceName)
+		case errors.Is(err, notion.ErrUnauthorized):
+			a.markTokenInvalid(account, err)
+			rejected = err
+		case errors.Is(err, notion.ErrTokenNotSet):
+		default:
+			slog.Warn("verify notion token failed", "account", account, "error", err)
+		}
+	}
+	if rejected != nil {
+		a.publishState(rejected)
+	}
+}
+
+func (a *App) tokenStoreFor(account string) store.TokenStore {
+	account = strings.TrimSpace(account)
+	if accounts, ok := a.tokenStore.(store.AccountTokenStore); ok && account != "" {
+		return accounts.ForAccount(account)
+	}
+	return a.tokenStore
+}
+
+// tokenAccount は DB や Brain プロファイルの account を実際に使うアカウント ID にする。空なら有効なプロファイルのトークン。
+func tokenAccount(cfg dto.Config, account string) string {
+	if account = strings.TrimSpace(account); account != "" {
+		return account
+	}
+	return cfg.ActiveTokenAccount()
+}
+
+// usedTokenAccounts は有効な DB と Brain プロファイルが使うアカウント ID を重複なく返す。
+func usedTokenAccounts(cfg dto.Config) []string {
+	seen := make(map[string]struct{})
+	var accounts []string
+	add := func(account string) {
+		account = tokenAccount(cfg, account)
+		if _, ok := seen[account]; ok {
+			return
+		}
+		seen[account] = struct{}{}
+		accounts = append(accounts, account)
+	}
+	for _, db := range cfg.Databases {
+		if db.Enabled {
+			add(db.Account)
+		}
+	}
+	for _, p := range cfg.BrainProfiles {
+		add(p.Account)
+	}
+	return accounts
+}
+
+// markTokenInvalid は Notion に拒否されたアカウントを記録する。毎分同じ 401 を繰り返さないよう、
+// トークンを保存し直すか手動で更新するまで、そのアカウントの DB は取得しない（他のアカウントの取得は続ける）。
+func (a *App) markTokenInvalid(account string, err error) {
+	a.stateMu.Lock()
+	_, marked := a.invalidAccounts[account]
+	a.invalidAccounts[account] = struct{}{}
+	a.stateMu.Unlock()
+	if !marked {
+		slog.Warn("notion token rejected, paused databases of the account", "account", account, "error", err)
+	}
+}
+
+func (a *App) tokenInvalid(account string) bool {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	_, ok := a.invalidAccounts[account]
+	return ok
+}
+
+// resumeAfterTokenChange は account のトークンが変わったとき、無効なトークンで止めていた DB の取得を再開する。
+// account が空なら有効なプロファイルのトークン。
+func (a *App) resumeAfterTokenChange(account string) {
+	account = tokenAccount(a.currentConfig(), account)
+	a.stateMu.Lock()
+	_, marked := a.invalidAccounts[account]
+	delete(a.invalidAccounts, account)
+	a.stateMu.Unlock()
+	if marked {
+		a.StartBackgroundPolling()
+	}
+}
+
+// pruneInvalidAccounts は設定から使われなくなったアカウントの無効の記録を消し、消したかを返す。
+func (a *App) pruneInvalidAccounts(cfg dto.Config) bool {
+	used := make(map[string]struct{})
+	for _, account := range usedTokenAccounts(cfg) {
+		used[account] = struct{}{}
+	}
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	pruned := false
+	for account := range a.invalidAccounts {
+		if _, ok := used[account]; !ok {
+			delete(a.invalidAccounts, account)
+			pruned = true
+		}
+	}
+	return pruned
+}
@@ -4,6 +4,8 @@ package dto
 type BrainTemplate struct {
 	Title string `json:"title"`
 	Body  string `json:"body"`
+	// HasPlaceholder はテンプレートに本文の差し込み位置があること（Body は空になる）を表す。
+	HasPlaceholder bool `json:"has_placeholder"`
 }
 
 // CreatedPage は作成済みページの最小情報。
@@ -11,3 +13,19 @@ type CreatedPage struct {
 	ID  string `json:"id"`
 	URL string `json:"url"`
 }
+
+// BrainDraft は Brain プロファイルごとの未登録の本文。
+type BrainDraft struct {
+	Profile   string `json:"profile"`
+	Body      string `json:"body"`
+	UpdatedAt string `json:"updated_at,omitempty"` // RFC3339
+}
+
+// BrainNote は Brain ウィンドウから登録したメモの履歴 1 件。
+type BrainNote struct {
+	Profile   string `json:"profile"`
+	Excerpt   string `json:"excerpt"` // 本文の先頭行
+	PageID    string `json:"page_id"`
+	URL       string `json:"url"`
+	CreatedAt string `json:"created_at"` // RFC3339
+}
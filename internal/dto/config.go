@@ -3,12 +3,35 @@ package dto
 import (
 	"fmt"
 	"strings"
+	"time"
+
+	"nudge/internal/notion/notionid"
 )
 
 const (
 	DatabaseKindTask  = "task"
 	DatabaseKindHabit = "habit"
 	DefaultHabitDays  = "日,月,火,水,木,金,土"
+
+	TrayLabelNone  = "none"
+	TrayLabelCount = "count"
+	TrayLabelTitle = "title"
+
+	DefaultPollIntervalSeconds = 60
+	DefaultMaxResults          = 30
+	DefaultTrayLabelMaxLength  = 20
+
+	DefaultSyncFailureThreshold = 3
+	DefaultDueDigestTime        = "08:00"
+	DefaultDailyReviewTime      = "21:00"
+	DefaultDailyReviewTitle     = "日次レビュー"
+	DefaultBrainProfileName     = "Brain"
+	DefaultProfileName          = "default"
+	DefaultOAuthAuthorizeURL    = "https://api.notion.com/v1/oauth/authorize"
+	DefaultOAuthTokenURL        = "https://api.notion.com/v1/oauth/token"
+
+	// ConfigVersion は現在の設定ファイルの形式の版。旧版のファイルは読み込み時に store が移行する。
+	ConfigVersion = 2
 )
 
 // DatabaseConfig はデータベースごとの設定。
@@ -26,24 +49,115 @@ type DatabaseConfig struct {
 	StatusDone           string `json:"status_done"`
 	StatusPaused         string `json:"status_paused"`
 	CheckboxPropertyName string `json:"checkbox_property_name"`
+	DuePropertyName      string `json:"due_property_name"`  // 任意。期限（date 型）のプロパティ名
+	TimePropertyName     string `json:"time_property_name"` // 任意。集中した分数を加算する number 型のプロパティ名
+	// AssigneePropertyName は任意。担当者（people 型）のプロパティ名。割り当ての通知に使う
+	AssigneePropertyName string `json:"assignee_property_name"`
+	// TimeTotalPropertyName は任意。作業時間台帳の累計分数で上書きする number 型のプロパティ名
+	TimeTotalPropertyName string `json:"time_total_property_name"`
+	// Account は任意。この DB の取得・更新に使うトークンのアカウント ID。空なら有効なプロファイルのトークン
+	Account string `json:"account,omitempty"`
 }
 
 // Config はローカル設定ファイルの内容。
 type Config struct {
-	Databases           []DatabaseConfig `json:"databases"`
-	PollIntervalSeconds int              `json:"poll_interval_seconds"`
-	MaxResults          int              `json:"max_results"`
-	LaunchAtLogin       bool             `json:"launch_at_login"`
-	TrayIconPath        string           `json:"tray_icon_path"`
-	NotionVersion       string           `json:"notion_version"`
-	BrainDatabaseID     string           `json:"brain_database_id"`
-	BrainTemplatePageID string           `json:"brain_template_page_id"`
+	Version             int                `json:"version"`
+	Databases           []DatabaseConfig   `json:"databases"`
+	PollIntervalSeconds int                `json:"poll_interval_seconds"`
+	MaxResults          int                `json:"max_results"`
+	LaunchAtLogin       bool               `json:"launch_at_login"`
+	TrayIconPath        string             `json:"tray_icon_path"`
+	TrayLabelMode       string             `json:"tray_label_mode"` // "none" | "count" | "title"
+	TrayLabelMaxLength  int                `json:"tray_label_max_length"`
+	NotionVersion       string             `json:"notion_version"`
+	BrainProfiles       []BrainProfile     `json:"brain_profiles"`
+	Notifications       NotificationConfig `json:"notifications"`
+	DueReminders        DueReminderConfig  `json:"due_reminders"`
+	FocusMinutes        int                `json:"focus_minutes"`
+	DailyReview         DailyReviewConfig  `json:"daily_review"`
+	OAuth               OAuthConfig        `json:"oauth"`
+	// Profiles は名前付きのプロファイル。空ならプロファイルを使わず Databases だけで動く
+	Profiles      []ConfigProfile `json:"profiles,omitempty"`
+	ActiveProfile string          `json:"active_profile,omitempty"`
+	// Revision は読み込んだときの設定ファイルの内容のハッシュ。ファイルには書かず、保存時に外部での変更を検出するのに使う
+	Revision string `json:"revision,omitempty"`
+}
+
+// OAuthConfig は公開インテグレーションとして OAuth で Notion と接続する設定。クライアントシークレットは Keychain に置く。
+type OAuthConfig struct {
+	ClientID     string `json:"client_id"` // 空なら OAuth を使わない
+	AuthorizeURL string `json:"authorize_url"`
+	TokenURL     string `json:"token_url"` // シークレットを付けて中継するサーバを指してもよい
+	// RedirectPort はリダイレクトを受けるループバックのポート。Notion に登録したリダイレクト URI と合わせる。0 なら空いているポート
+	RedirectPort int `json:"redirect_port"`
+}
+
+// DailyReviewConfig は 1 日のまとめページを Brain DB に作成する設定。
+type DailyReviewConfig struct {
+	Enabled bool   `json:"enabled"` // 有効なら Time に自動作成する（無効でも手動では作成できる）
+	Time    string `json:"time"`    // "HH:MM"
+	Title   string `json:"title"`   // ページタイトルの接頭辞。後ろに日付を付ける
+	Profile string `json:"profile"` // 作成先の Brain プロファイルのキー。空なら先頭
+}
+
+// BrainProfile は Brain ウィンドウから登録するメモの種類（作成先 DB とテンプレート）。
+type BrainProfile struct {
+	Key            string `json:"key"`
+	Name           string `json:"name"`
+	DatabaseID     string `json:"database_id"`
+	TemplatePageID string `json:"template_page_id"`
+	// Properties はプロパティ名と既定値。型はテンプレートのプロパティに合わせて変換する（multi_select はカンマ区切り）。
+	Properties map[string]string `json:"properties,omitempty"`
+	// TitlePrefix / TitleDate を指定するとテンプレートのタイトルの代わりに "接頭辞 YYYY-MM-DD" をタイトルにする。
+	TitlePrefix string `json:"title_prefix"`
+	TitleDate   bool   `json:"title_date"`
+	// Account は任意。このプロファイルのページ作成に使うトークンのアカウント ID。空なら有効なプロファイルのトークン
+	Account string `json:"account,omitempty"`
+}
+
+// DueReminderConfig は期限リマインダーの設定。期限は due_property_name を持つタスク DB のみ対象。
+type DueReminderConfig struct {
+	AtDue         bool   `json:"at_due"`       // 期限時刻ちょうど（時刻付きの期限のみ）
+	LeadMinutes   int    `json:"lead_minutes"` // 期限の N 分前。0 なら送らない
+	MorningDigest bool   `json:"morning_digest"`
+	DigestTime    string `json:"digest_time"` // "HH:MM"
+}
+
+// NotificationConfig はデスクトップ通知の種類ごとの設定。
+type NotificationConfig struct {
+	TaskStarted          bool     `json:"task_started"`
+	TaskEdited           bool     `json:"task_edited"`
+	TaskAssigned         bool     `json:"task_assigned"`
+	HabitReminder        bool     `json:"habit_reminder"`
+	HabitReminderTimes   []string `json:"habit_reminder_times"` // "HH:MM"
+	SyncFailure          bool     `json:"sync_failure"`
+	SyncFailureThreshold int      `json:"sync_failure_threshold"`
+	// NotionUserID は自分の Notion ユーザー ID。一致する編集は「他人の編集」として通知しない。
+	NotionUserID string `json:"notion_user_id"`
 }
 
 func DefaultConfig() Config {
 	cfg := Config{
-		PollIntervalSeconds: 60,
-		MaxResults:          30,
+		Version:             ConfigVersion,
+		PollIntervalSeconds: DefaultPollIntervalSeconds,
+		MaxResults:          DefaultMaxResults,
+		TrayLabelMode:       TrayLabelNone,
+		TrayLabelMaxLength:  DefaultTrayLabelMaxLength,
+		Notifications: NotificationConfig{
+			SyncFailureThreshold: DefaultSyncFailureThreshold,
+		},
+		DueReminders: DueReminderConfig{
+			DigestTime: DefaultDueDigestTime,
+		},
+		FocusMinutes: DefaultFocusMinutes,
+		DailyReview: DailyReviewConfig{
+			Time:  DefaultDailyReviewTime,
+			Title: DefaultDailyReviewTitle,
+		},
+		OAuth: OAuthConfig{
+			AuthorizeURL: DefaultOAuthAuthorizeURL,
+			TokenURL:     DefaultOAuthTokenURL,
+		},
 	}
 	cfg.Databases = defaultDatabases()
 	return cfg
@@ -54,7 +168,146 @@ func (c Config) Normalize() Config {
 		c.Databases = defaultDatabases()
 	}
 	c.Databases = normalizeDatabases(c.Databases)
-	return c
+	if c.PollIntervalSeconds <= 0 {
+		c.PollIntervalSeconds = DefaultPollIntervalSeconds
+	}
+	if c.MaxResults <= 0 {
+		c.MaxResults = DefaultMaxResults
+	}
+	switch strings.TrimSpace(c.TrayLabelMode) {
+	case TrayLabelCount, TrayLabelTitle:
+		c.TrayLabelMode = strings.TrimSpace(c.TrayLabelMode)
+	default:
+		c.TrayLabelMode = TrayLabelNone
+	}
+	if c.TrayLabelMaxLength <= 0 {
+		c.TrayLabelMaxLength = DefaultTrayLabelMaxLength
+	}
+	c.Notifications = c.Notifications.Normalize()
+	c.DueReminders = c.DueReminders.Normalize()
+	if c.FocusMinutes <= 0 {
+		c.FocusMinutes = DefaultFocusMinutes
+	}
+	c.DailyReview = c.DailyReview.Normalize()
+	c.OAuth = c.OAuth.Normalize()
+	c.BrainProfiles = normalizeBrainProfiles(c.BrainProfiles)
+	return c.normalizeProfiles()
+}
+
+func (o OAuthConfig) Normalize() OAuthConfig {
+	o.ClientID = strings.TrimSpace(o.ClientID)
+	o.AuthorizeURL = strings.TrimSpace(o.AuthorizeURL)
+	if o.AuthorizeURL == "" {
+		o.AuthorizeURL = DefaultOAuthAuthorizeURL
+	}
+	o.TokenURL = strings.TrimSpace(o.TokenURL)
+	if o.TokenURL == "" {
+		o.TokenURL = DefaultOAuthTokenURL
+	}
+	return o
+}
+
+func (d DailyReviewConfig) Normalize() DailyReviewConfig {
+	if _, _, ok := ParseClock(d.Time); !ok {
+		d.Time = DefaultDailyReviewTime
+	}
+	d.Time = strings.TrimSpace(d.Time)
+	d.Title = strings.TrimSpace(d.Title)
+	if d.Title == "" {
+		d.Title = DefaultDailyReviewTitle
+	}
+	d.Profile = strings.TrimSpace(d.Profile)
+	return d
+}
+
+// PageTitle は title_prefix と日付からページタイトルを組み立てる。どちらもなければ空（テンプレートのタイトルを使う）。
+func (p BrainProfile) PageTitle(now time.Time) string {
+	var parts []string
+	if p.TitlePrefix != "" {
+		parts = append(parts, p.TitlePrefix)
+	}
+	if p.TitleDate {
+		parts = append(parts, now.Format(time.DateOnly))
+	}
+	return strings.Join(parts, " ")
+}
+
+func normalizeBrainProfiles(profiles []BrainProfile) []BrainProfile {
+	used := make(map[string]struct{}, len(profiles))
+	for i := range profiles {
+		p := &profiles[i]
+		p.Key = strings.TrimSpace(p.Key)
+		if p.Key == "" {
+			p.Key = fmt.Sprintf("brain-%d", i+1)
+		}
+		key := p.Key
+		for {
+			if _, ok := used[key]; !ok {
+				break
+			}
+			key = fmt.Sprintf("%s-%d", p.Key, i+1)
+		}
+		p.Key = key
+		used[key] = struct{}{}
+
+		p.Name = strings.TrimSpace(p.Name)
+		if p.Name == "" {
+			p.Name = DefaultBrainProfileName
+		}
+		p.DatabaseID = notionid.Normalize(p.DatabaseID)
+		p.TemplatePageID = notionid.Normalize(p.TemplatePageID)
+		p.TitlePrefix = strings.TrimSpace(p.TitlePrefix)
+		p.Account = strings.TrimSpace(p.Account)
+		props := make(map[string]string, len(p.Properties))
+		for name, value := range p.Properties {
+			if name = strings.TrimSpace(name); name != "" {
+				props[name] = strings.TrimSpace(value)
+			}
+		}
+		p.Properties = props
+		if len(props) == 0 {
+			p.Properties = nil
+		}
+	}
+	return profiles
+}
+
+func (d DueReminderConfig) Normalize() DueReminderConfig {
+	if d.LeadMinutes < 0 {
+		d.LeadMinutes = 0
+	}
+	if _, _, ok := ParseClock(d.DigestTime); !ok {
+		d.DigestTime = DefaultDueDigestTime
+	}
+	d.DigestTime = strings.TrimSpace(d.DigestTime)
+	return d
+}
+
+func (n NotificationConfig) Normalize() NotificationConfig {
+	if n.SyncFailureThreshold <= 0 {
+		n.SyncFailureThreshold = DefaultSyncFailureThreshold
+	}
+	times := make([]string, 0, len(n.HabitReminderTimes))
+	for _, value := range n.HabitReminderTimes {
+		if _, _, ok := ParseClock(value); ok {
+			times = append(times, strings.TrimSpace(value))
+		}
+	}
+	n.HabitReminderTimes = times
+	n.NotionUserID = strings.TrimSpace(n.NotionUserID)
+	return n
+}
+
+// ParseClock は "HH:MM" 形式の時刻を時・分に分解する。
+func ParseClock(value string) (int, int, bool) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(strings.TrimSpace(value), "%d:%d", &hour, &minute); err != nil {
+		return 0, 0, false
+	}
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
 }
 
 func (c Config) DatabaseByKey(key string) (DatabaseConfig, bool) {
@@ -66,6 +319,16 @@ func (c Config) DatabaseByKey(key string) (DatabaseConfig, bool) {
 	return DatabaseConfig{}, false
 }
 
+// BrainProfileByKey はキーに一致する Brain プロファイルを返す。key が空なら先頭を返す。
+func (c Config) BrainProfileByKey(key string) (BrainProfile, bool) {
+	for _, p := range c.BrainProfiles {
+		if key == "" || p.Key == key {
+			return p, true
+		}
+	}
+	return BrainProfile{}, false
+}
+
 func (c Config) FirstDatabaseByKind(kind string) (DatabaseConfig, bool) {
 	for _, db := range c.Databases {
 		if db.Kind == kind {
@@ -123,20 +386,19 @@ func (d DatabaseConfig) StatusForAction(action string) string {
 	}
 }
 
+// defaultDatabases は設定がないときの DB の雛形。ID を設定して有効にするまでは検証やポーリングの対象にしない。
 func defaultDatabases() []DatabaseConfig {
 	return []DatabaseConfig{
 		{
 			Key:                "tasks",
 			Name:               "タスク",
 			Kind:               DatabaseKindTask,
-			Enabled:            true,
 			StatusPropertyType: "status",
 		},
 		{
 			Key:                  "habits",
 			Name:                 "習慣",
 			Kind:                 DatabaseKindHabit,
-			Enabled:              true,
 			TitlePropertyName:    "名前",
 			CheckboxPropertyName: DefaultHabitDays,
 		},
@@ -164,6 +426,14 @@ func normalizeDatabases(dbs []DatabaseConfig) []DatabaseConfig {
 		if dbs[i].Kind == "" {
 			dbs[i].Kind = DatabaseKindTask
 		}
+		// Notion からコピーした URL やハイフン付きの ID もそのまま貼れるようにする
+		dbs[i].DatabaseID = notionid.Normalize(dbs[i].DatabaseID)
+		dbs[i].DataSourceID = notionid.Normalize(dbs[i].DataSourceID)
+		dbs[i].DuePropertyName = strings.TrimSpace(dbs[i].DuePropertyName)
+		dbs[i].TimePropertyName = strings.TrimSpace(dbs[i].TimePropertyName)
+		dbs[i].AssigneePropertyName = strings.TrimSpace(dbs[i].AssigneePropertyName)
+		dbs[i].TimeTotalPropertyName = strings.TrimSpace(dbs[i].TimeTotalPropertyName)
+		dbs[i].Account = strings.TrimSpace(dbs[i].Account)
 		dbs[i].Name = strings.TrimSpace(dbs[i].Name)
 		if dbs[i].Name == "" {
 			dbs[i].Name = defaultNameForKind(dbs[i].Kind)
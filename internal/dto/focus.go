@@ -0,0 +1,46 @@
+package dto
+
+import "time"
+
+const DefaultFocusMinutes = 25
+
+// FocusSession はタスクに紐づく集中セッション（ポモドーロ）の状態。
+type FocusSession struct {
+	DatabaseKey     string `json:"database_key"`
+	TaskID          string `json:"task_id"`
+	TaskTitle       string `json:"task_title"`
+	TaskURL         string `json:"task_url"`
+	StartedAt       string `json:"started_at"` // RFC3339
+	DurationSeconds int    `json:"duration_seconds"`
+	// ElapsedSeconds は一時停止までに計測済みの秒数（計測中の区間は含まない）。
+	ElapsedSeconds int `json:"elapsed_seconds"`
+	// ResumedAt は計測中の区間の開始時刻。一時停止中は空。
+	ResumedAt        string `json:"resumed_at"`
+	Paused           bool   `json:"paused"`
+	RemainingSeconds int    `json:"remaining_seconds"` // 取得時点の残り秒数（参照用）
+}
+
+// Elapsed は now 時点の経過時間を返す。
+func (s FocusSession) Elapsed(now time.Time) time.Duration {
+	elapsed := time.Duration(s.ElapsedSeconds) * time.Second
+	if s.Paused || s.ResumedAt == "" {
+		return elapsed
+	}
+	resumed, err := time.Parse(time.RFC3339, s.ResumedAt)
+	if err != nil {
+		return elapsed
+	}
+	if d := now.Sub(resumed); d > 0 {
+		elapsed += d
+	}
+	return elapsed
+}
+
+// Remaining は now 時点の残り時間を返す（0 未満にはならない）。
+func (s FocusSession) Remaining(now time.Time) time.Duration {
+	remaining := time.Duration(s.DurationSeconds)*time.Second - s.Elapsed(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
@@ -0,0 +1,20 @@
+package dto
+
+// Nudge から行った操作の種類。
+const (
+	ActionTaskDone       = "task_done"
+	ActionTaskPaused     = "task_paused"
+	ActionTaskResumed    = "task_resumed"
+	ActionHabitChecked   = "habit_checked"
+	ActionHabitUnchecked = "habit_unchecked"
+)
+
+// ActionRecord は操作履歴の 1 行（JSON Lines）。
+type ActionRecord struct {
+	Action      string `json:"action"`
+	DatabaseKey string `json:"database_key"`
+	PageID      string `json:"page_id"`
+	Title       string `json:"title,omitempty"`
+	URL         string `json:"url,omitempty"`
+	At          string `json:"at"` // RFC3339
+}
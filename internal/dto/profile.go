@@ -0,0 +1,166 @@
+package dto
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// ProfileBundleFormat は書き出したプロファイルのファイルを見分けるための format の値。
+	ProfileBundleFormat = "nudge-profile"
+	// ProfileBundleVersion は書き出し形式の版。
+	ProfileBundleVersion = 1
+)
+
+// ConfigProfile は名前付きの設定プロファイル（仕事用・個人用のワークスペースなど）。
+// 有効なプロファイルの DB は最上位の Databases を正とし、Normalize で写し取る。
+type ConfigProfile struct {
+	Name string `json:"name"`
+	// TokenAccount はこのプロファイルで使うトークンの保存先のアカウント ID。空なら既定のトークンを使う
+	TokenAccount string           `json:"token_account,omitempty"`
+	Databases    []DatabaseConfig `json:"databases"`
+}
+
+// ProfileBundle はプロファイルを共有するための書き出し形式。トークンとその参照は含めない。
+type ProfileBundle struct {
+	Format        string           `json:"format"`
+	Version       int              `json:"version"`
+	Name          string           `json:"name"`
+	NotionVersion string           `json:"notion_version,omitempty"`
+	Databases     []DatabaseConfig `json:"databases"`
+}
+
+// ProfileByName は名前に一致するプロファイルを返す。
+func (c Config) ProfileByName(name string) (ConfigProfile, bool) {
+	for _, p := range c.Profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return ConfigProfile{}, false
+}
+
+// ActiveTokenAccount は有効なプロファイルのトークンのアカウント ID を返す。プロファイルがなければ空。
+func (c Config) ActiveTokenAccount() string {
+	p, _ := c.ProfileByName(c.ActiveProfile)
+	return p.TokenAccount
+}
+
+// SwitchProfile は name のプロファイルの DB を最上位に読み込んだ設定を返す。今の DB は元のプロファイルに残す。
+func (c Config) SwitchProfile(name string) (Config, error) {
+	c = c.Normalize()
+	target, ok := c.ProfileByName(name)
+	if !ok {
+		return c, fmt.Errorf("profile not found: %s", name)
+	}
+	c.Profiles = slices.Clone(c.Profiles)
+	c.Databases = slices.Clone(target.Databases)
+	c.ActiveProfile = target.Name
+	return c.Normalize(), nil
+}
+
+// ExportProfile は name のプロファイルを書き出し形式にする。DB ごとのトークンの参照（Account）は外す。
+func (c Config) ExportProfile(name string) (ProfileBundle, error) {
+	c = c.Normalize()
+	p, ok := c.ProfileByName(name)
+	if !ok {
+		return ProfileBundle{}, fmt.Errorf("profile not found: %s", name)
+	}
+	dbs := slices.Clone(p.Databases)
+	for i := range dbs {
+		dbs[i].Account = ""
+	}
+	return ProfileBundle{
+		Format:        ProfileBundleFormat,
+		Version:       ProfileBundleVersion,
+		Name:          p.Name,
+		NotionVersion: c.NotionVersion,
+		Databases:     dbs,
+	}, nil
+}
+
+// ImportProfile は書き出し形式のプロファイルを追加した設定を返す。同じ名前があれば DB を置き換え、トークンの参照は残す。
+// 雛形の DB しかない設定（初回起動直後など）では、取り込んだプロファイルを有効にする。
+func (c Config) ImportProfile(bundle ProfileBundle) (Config, error) {
+	if bundle.Format != ProfileBundleFormat {
+		return c, fmt.Errorf("not a profile bundle: format is %q", bundle.Format)
+	}
+	if bundle.Version > ProfileBundleVersion {
+		return c, fmt.Errorf("profile bundle version %d is newer than supported version %d", bundle.Version, ProfileBundleVersion)
+	}
+	name := strings.TrimSpace(bundle.Name)
+	if name == "" {
+		return c, fmt.Errorf("profile name is empty")
+	}
+	if len(bundle.Databases) == 0 {
+		return c, fmt.Errorf("profile bundle has no databases")
+	}
+	c = c.Normalize()
+	dbs := slices.Clone(bundle.Databases)
+	c.Profiles = slices.Clone(c.Profiles)
+	if i := slices.IndexFunc(c.Profiles, func(p ConfigProfile) bool { return p.Name == name }); i >= 0 {
+		c.Profiles[i].Databases = dbs
+		if c.ActiveProfile == name {
+			c.Databases = slices.Clone(dbs)
+		}
+	} else {
+		switch {
+		case len(c.Profiles) > 0:
+		case !hasDatabaseID(c.Databases):
+			// ID のない雛形の DB しかなければ残さず、取り込んだプロファイルを使い始める
+			c.Databases = slices.Clone(dbs)
+			c.ActiveProfile = name
+		default:
+			// 初めてのプロファイル追加では、今の設定を既定のプロファイルとして残す
+			c.Profiles = []ConfigProfile{{Name: DefaultProfileName, Databases: slices.Clone(c.Databases)}}
+			c.ActiveProfile = DefaultProfileName
+		}
+		c.Profiles = append(c.Profiles, ConfigProfile{Name: name, Databases: dbs})
+	}
+	if c.NotionVersion == "" {
+		c.NotionVersion = strings.TrimSpace(bundle.NotionVersion)
+	}
+	return c.Normalize(), nil
+}
+
+// hasDatabaseID は database_id か data_source_id が設定された DB があるかを返す。
+func hasDatabaseID(dbs []DatabaseConfig) bool {
+	return slices.ContainsFunc(dbs, func(db DatabaseConfig) bool {
+		return strings.TrimSpace(db.DatabaseID) != "" || strings.TrimSpace(db.DataSourceID) != ""
+	})
+}
+
+// normalizeProfiles は名前を揃えて重複を除き、有効なプロファイルに最上位の DB を写す。
+func (c Config) normalizeProfiles() Config {
+	if len(c.Profiles) == 0 {
+		c.ActiveProfile = ""
+		return c
+	}
+	profiles := make([]ConfigProfile, 0, len(c.Profiles))
+	used := make(map[string]struct{}, len(c.Profiles))
+	for i, p := range c.Profiles {
+		p.Name = strings.TrimSpace(p.Name)
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("profile-%d", i+1)
+		}
+		if _, ok := used[p.Name]; ok {
+			continue
+		}
+		used[p.Name] = struct{}{}
+		p.TokenAccount = strings.TrimSpace(p.TokenAccount)
+		p.Databases = normalizeDatabases(slices.Clone(p.Databases))
+		profiles = append(profiles, p)
+	}
+	c.ActiveProfile = strings.TrimSpace(c.ActiveProfile)
+	if _, ok := used[c.ActiveProfile]; !ok {
+		c.ActiveProfile = profiles[0].Name
+	}
+	for i := range profiles {
+		if profiles[i].Name == c.ActiveProfile {
+			profiles[i].Databases = slices.Clone(c.Databases)
+		}
+	}
+	c.Profiles = profiles
+	return c
+}
@@ -0,0 +1,23 @@
+package dto
+
+// SyncState はバックグラウンド更新の最新結果（トレイ表示などに使う）。
+type SyncState struct {
+	Counts Counts `json:"counts"`
+	// TopTask は最も最近更新された進行中タスク。無ければ nil。
+	TopTask   *Task  `json:"top_task,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+	// TokenInvalid は InvalidTokenAccounts が空でないこと。
+	TokenInvalid bool `json:"token_invalid,omitempty"`
+	// InvalidTokenAccounts はトークンが Notion に拒否され、DB の取得を止めているアカウント ID（空文字は既定のトークン）。
+	// トークンを保存し直すか手動で更新するまで続く。
+	InvalidTokenAccounts []string `json:"invalid_token_accounts,omitempty"`
+	UpdatedAt            string   `json:"updated_at"`
+}
+
+// DatabaseSnapshot は有効な DB ごとのキャッシュ内容。
+type DatabaseSnapshot struct {
+	Key   string `json:"key"`
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	Items []Task `json:"items"`
+}
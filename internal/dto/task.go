@@ -1,5 +1,11 @@
 package dto
 
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
 // Task は UI に渡す最小単位のタスク情報。
 type Task struct {
 	ID             string `json:"id"`
@@ -7,5 +13,62 @@ type Task struct {
 	URL            string `json:"url"`
 	Status         string `json:"status"`
 	LastEditedTime string `json:"last_edited_time"`
+	LastEditedBy   string `json:"last_edited_by,omitempty"`
 	Checked        bool   `json:"checked"`
+	Due            string `json:"due,omitempty"` // 期限（Notion の date.start。日付のみ or 日時）
+	Overdue        bool   `json:"overdue"`
+	// Assignees は担当者の Notion ユーザー ID。assignee_property_name を設定した DB のみ
+	Assignees []string `json:"assignees,omitempty"`
+}
+
+// DueTime は期限を解釈する。日付のみの期限は loc の 0 時として返し、hasTime は false。
+func (t Task) DueTime(loc *time.Location) (due time.Time, hasTime bool, ok bool) {
+	value := strings.TrimSpace(t.Due)
+	if value == "" {
+		return time.Time{}, false, false
+	}
+	if len(value) == len("2006-01-02") {
+		d, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return time.Time{}, false, false
+		}
+		return d, false, true
+	}
+	d, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, false
+	}
+	return d.In(loc), true, true
+}
+
+// IsOverdue は now 時点で期限を過ぎているかを返す。日付のみの期限は当日中は期限内とする。
+func (t Task) IsOverdue(now time.Time) bool {
+	due, hasTime, ok := t.DueTime(now.Location())
+	if !ok {
+		return false
+	}
+	if !hasTime {
+		return !now.Before(due.AddDate(0, 0, 1))
+	}
+	return now.After(due)
+}
+
+// Equal は t と other の内容が同じかを返す。
+func (t Task) Equal(other Task) bool {
+	return t.ID == other.ID &&
+		t.Title == other.Title &&
+		t.URL == other.URL &&
+		t.Status == other.Status &&
+		t.LastEditedTime == other.LastEditedTime &&
+		t.LastEditedBy == other.LastEditedBy &&
+		t.Checked == other.Checked &&
+		t.Due == other.Due &&
+		t.Overdue == other.Overdue &&
+		slices.Equal(t.Assignees, other.Assignees)
+}
+
+// Counts はキャッシュ上のタスク/未完了習慣の件数。
+type Counts struct {
+	Tasks  int `json:"tasks"`
+	Habits int `json:"habits"`
 }
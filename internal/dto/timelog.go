@@ -0,0 +1,40 @@
+package dto
+
+const (
+	TimeEventStart  = "start"
+	TimeEventStop   = "stop"
+	TimeEventCancel = "cancel" // 直前の start を破棄する（記録しない）
+
+	TimePeriodDay  = "day"
+	TimePeriodWeek = "week"
+)
+
+// TimeEvent は作業時間台帳の 1 行（JSON Lines）。
+type TimeEvent struct {
+	Type        string `json:"type"`
+	PageID      string `json:"page_id"`
+	DatabaseKey string `json:"database_key"`
+	Title       string `json:"title,omitempty"`
+	At          string `json:"at"` // RFC3339
+}
+
+// TimeSummary は期間内の作業時間を DB / タスク別に集計したもの。
+type TimeSummary struct {
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	TotalSeconds int            `json:"total_seconds"`
+	Databases    []DatabaseTime `json:"databases"`
+}
+
+type DatabaseTime struct {
+	Key     string     `json:"key"`
+	Name    string     `json:"name"`
+	Seconds int        `json:"seconds"`
+	Tasks   []TaskTime `json:"tasks"`
+}
+
+type TaskTime struct {
+	PageID  string `json:"page_id"`
+	Title   string `json:"title"`
+	Seconds int    `json:"seconds"`
+}
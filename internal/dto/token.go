@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReservedTokenAccountPrefix はアプリが内部で使う TokenStore のアカウント ID（OAuth のクライアントシークレットなど）の接頭辞。
+// 利用者が付けるアカウント ID には使えない。
+const ReservedTokenAccountPrefix = "@"
+
+// ValidateTokenAccount は利用者が付けたアカウント ID が予約された名前と重ならないかを確かめる。
+func ValidateTokenAccount(account string) error {
+	if strings.HasPrefix(strings.TrimSpace(account), ReservedTokenAccountPrefix) {
+		return fmt.Errorf("token account must not start with %q", ReservedTokenAccountPrefix)
+	}
+	return nil
+}
+
+// TokenIdentity はトークンが指す Notion のインテグレーション（ボットユーザー）。
+type TokenIdentity struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	WorkspaceName string `json:"workspace_name,omitempty"`
+}
+
+// NotionWorkspace はトークンが接続しているワークスペースの情報。設定ディレクトリの workspaces.json にアカウントごとに保存する。
+type NotionWorkspace struct {
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Icon  string `json:"icon,omitempty"`
+	BotID string `json:"bot_id,omitempty"`
+}
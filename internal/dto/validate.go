@@ -0,0 +1,267 @@
+package dto
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"nudge/internal/notion/notionid"
+)
+
+// 数値項目の許容範囲。
+const (
+	minPollIntervalSeconds = 10
+	maxPollIntervalSeconds = 24 * 60 * 60
+	maxMaxResults          = 100 // Notion API の page_size の上限
+	maxTrayLabelMaxLength  = 100
+	maxFocusMinutes        = 8 * 60
+	maxLeadMinutes         = 24 * 60
+	maxSyncFailureCount    = 100
+	maxPort                = 65535
+)
+
+// FieldError は設定項目 1 つの検証エラー。Path は "databases[1].status_done" のような JSON 上の位置。
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors は Validate が見つけたエラーの一覧。
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, fe := range e {
+		lines[i] = fe.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate は取り込むプロファイルの DB と Notion Version を検証する。誤りがなければ nil。
+func (b ProfileBundle) Validate() ValidationErrors {
+	var errs ValidationErrors
+	add := func(path, format string, args ...any) {
+		errs = append(errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	validateDatabases(add, "databases", b.Databases)
+	validateNotionVersion(add, b.NotionVersion)
+	return errs
+}
+
+// ValidateProfile は name のプロファイルの DB とトークンのアカウント ID だけを検証する。誤りがなければ nil。
+// プロファイルの切り替えで使い、切り替えに関係しない項目の誤りでは切り替えを止めない。
+func (c Config) ValidateProfile(name string) ValidationErrors {
+	var errs ValidationErrors
+	add := func(path, format string, args ...any) {
+		errs = append(errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	for i, p := range c.Profiles {
+		if p.Name != name {
+			continue
+		}
+		path := fmt.Sprintf("profiles[%d]", i)
+		validateTokenAccount(add, path+".token_account", p.TokenAccount)
+		validateDatabases(add, path+".databases", p.Databases)
+		break
+	}
+	return errs
+}
+
+// Validate は設定の誤りを項目ごとに返す。誤りがなければ nil。
+// Normalize が黙って直してしまう重複キーなども検出できるよう、Normalize 前の値にも使える。
+func (c Config) Validate() ValidationErrors {
+	var errs ValidationErrors
+	add := func(path, format string, args ...any) {
+		errs = append(errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	validateDatabases(add, "databases", c.Databases)
+
+	validateNotionVersion(add, c.NotionVersion)
+	validateRange(add, "poll_interval_seconds", c.PollIntervalSeconds, minPollIntervalSeconds, maxPollIntervalSeconds)
+	validateRange(add, "max_results", c.MaxResults, 1, maxMaxResults)
+	switch strings.TrimSpace(c.TrayLabelMode) {
+	case "", TrayLabelNone, TrayLabelCount, TrayLabelTitle:
+	default:
+		add("tray_label_mode", "must be %q, %q or %q", TrayLabelNone, TrayLabelCount, TrayLabelTitle)
+	}
+	validateRange(add, "tray_label_max_length", c.TrayLabelMaxLength, 0, maxTrayLabelMaxLength)
+	validateRange(add, "focus_minutes", c.FocusMinutes, 0, maxFocusMinutes)
+
+	for i, value := range c.Notifications.HabitReminderTimes {
+		validateClock(add, fmt.Sprintf("notifications.habit_reminder_times[%d]", i), value)
+	}
+	validateRange(add, "notifications.sync_failure_threshold", c.Notifications.SyncFailureThreshold, 0, maxSyncFailureCount)
+	validateNotionID(add, "notifications.notion_user_id", c.Notifications.NotionUserID)
+	validateRange(add, "due_reminders.lead_minutes", c.DueReminders.LeadMinutes, 0, maxLeadMinutes)
+	if c.DueReminders.DigestTime != "" {
+		validateClock(add, "due_reminders.digest_time", c.DueReminders.DigestTime)
+	}
+
+	profiles := make(map[string]int, len(c.BrainProfiles))
+	for i, p := range c.BrainProfiles {
+		path := fmt.Sprintf("brain_profiles[%d]", i)
+		if key := strings.TrimSpace(p.Key); key != "" {
+			if first, ok := profiles[key]; ok {
+				add(path+".key", "duplicate key %q (also used by brain_profiles[%d])", key, first)
+			} else {
+				profiles[key] = i
+			}
+		}
+		validateTokenAccount(add, path+".account", p.Account)
+		if strings.TrimSpace(p.DatabaseID) == "" {
+			add(path+".database_id", "required")
+		} else {
+			validateNotionID(add, path+".database_id", p.DatabaseID)
+		}
+		if strings.TrimSpace(p.TemplatePageID) == "" {
+			add(path+".template_page_id", "required")
+		} else {
+			validateNotionID(add, path+".template_page_id", p.TemplatePageID)
+		}
+	}
+	if c.DailyReview.Time != "" {
+		validateClock(add, "daily_review.time", c.DailyReview.Time)
+	}
+	if key := strings.TrimSpace(c.DailyReview.Profile); key != "" {
+		if _, ok := profiles[key]; !ok {
+			add("daily_review.profile", "unknown brain profile %q", key)
+		}
+	}
+	if c.DailyReview.Enabled && len(c.BrainProfiles) == 0 {
+		add("daily_review.enabled", "requires at least one brain profile")
+	}
+
+	if strings.TrimSpace(c.OAuth.ClientID) != "" {
+		validateHTTPURL(add, "oauth.authorize_url", c.OAuth.AuthorizeURL)
+		validateHTTPURL(add, "oauth.token_url", c.OAuth.TokenURL)
+	}
+	validateRange(add, "oauth.redirect_port", c.OAuth.RedirectPort, 0, maxPort)
+
+	names := make(map[string]int, len(c.Profiles))
+	for i, p := range c.Profiles {
+		validateTokenAccount(add, fmt.Sprintf("profiles[%d].token_account", i), p.TokenAccount)
+		name := strings.TrimSpace(p.Name)
+		if name == "" {
+			continue
+		}
+		if first, ok := names[name]; ok {
+			add(fmt.Sprintf("profiles[%d].name", i), "duplicate name %q (also used by profiles[%d])", name, first)
+		} else {
+			names[name] = i
+		}
+	}
+	if active := strings.TrimSpace(c.ActiveProfile); active != "" {
+		if _, ok := names[active]; !ok {
+			add("active_profile", "unknown profile %q", active)
+		}
+	}
+	return errs
+}
+
+// validateDatabases は DB の一覧を検証する。prefix はエラーの位置に付ける JSON 上の名前。
+func validateDatabases(add func(path, format string, args ...any), prefix string, dbs []DatabaseConfig) {
+	keys := make(map[string]int, len(dbs))
+	for i, db := range dbs {
+		path := fmt.Sprintf("%s[%d]", prefix, i)
+		if key := strings.TrimSpace(db.Key); key != "" {
+			if first, ok := keys[key]; ok {
+				add(path+".key", "duplicate key %q (also used by %s[%d])", key, prefix, first)
+			} else {
+				keys[key] = i
+			}
+		}
+		kind := strings.TrimSpace(db.Kind)
+		if kind == "" {
+			kind = DatabaseKindTask
+		}
+		if kind != DatabaseKindTask && kind != DatabaseKindHabit {
+			add(path+".kind", "must be %q or %q", DatabaseKindTask, DatabaseKindHabit)
+			continue
+		}
+		validateNotionID(add, path+".database_id", db.DatabaseID)
+		validateNotionID(add, path+".data_source_id", db.DataSourceID)
+		validateTokenAccount(add, path+".account", db.Account)
+		if !db.Enabled {
+			continue
+		}
+		// data_source_id は database_id から、習慣 DB のタイトルプロパティはスキーマから実行時に解決する
+		hasDatabaseID := strings.TrimSpace(db.DatabaseID) != ""
+		if !hasDatabaseID && strings.TrimSpace(db.DataSourceID) == "" {
+			add(path+".database_id", "database_id or data_source_id is required")
+		}
+		var required []struct{ name, value string }
+		if kind == DatabaseKindTask || !hasDatabaseID {
+			required = append(required, struct{ name, value string }{"title_property_name", db.TitlePropertyName})
+		}
+		if kind == DatabaseKindTask {
+			required = append(required,
+				struct{ name, value string }{"status_property_name", db.StatusPropertyName},
+				struct{ name, value string }{"status_in_progress", db.StatusInProgress},
+				struct{ name, value string }{"status_done", db.StatusDone},
+			)
+			if db.StatusPropertyType != "status" && db.StatusPropertyType != "select" {
+				add(path+".status_property_type", "must be \"status\" or \"select\"")
+			}
+		}
+		for _, field := range required {
+			if strings.TrimSpace(field.value) == "" {
+				add(path+"."+field.name, "required for %s kind", kind)
+			}
+		}
+	}
+}
+
+func validateTokenAccount(add func(path, format string, args ...any), path, value string) {
+	if err := ValidateTokenAccount(value); err != nil {
+		add(path, "must not start with %q (reserved)", ReservedTokenAccountPrefix)
+	}
+}
+
+func validateNotionID(add func(path, format string, args ...any), path, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" || notionid.Valid(value) {
+		return
+	}
+	add(path, "must be a 32-character ID, a UUID or a Notion URL")
+}
+
+// validateNotionVersion は空でない Notion Version が日付の形式かを確かめる。
+func validateNotionVersion(add func(path, format string, args ...any), value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	if _, err := time.Parse(time.DateOnly, value); err != nil {
+		add("notion_version", "must be a date in YYYY-MM-DD format")
+	}
+}
+
+// validateHTTPURL は空でない値が http(s) の URL かを確かめる。空は Normalize で既定値になる。
+func validateHTTPURL(add func(path, format string, args ...any), path, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add(path, "must be an http or https URL")
+	}
+}
+
+func validateRange(add func(path, format string, args ...any), path string, value, minValue, maxValue int) {
+	if value < minValue || value > maxValue {
+		add(path, "must be between %d and %d", minValue, maxValue)
+	}
+}
+
+func validateClock(add func(path, format string, args ...any), path, value string) {
+	if _, _, ok := ParseClock(value); !ok {
+		add(path, "must be a time in HH:MM format")
+	}
+}
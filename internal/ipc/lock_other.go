@@ -0,0 +1,21 @@
+//go:build !unix
+
+package ipc
+
+import (
+	"net"
+)
+
+// lockInstance は flock のない環境では、ソケットに応答するプロセスがあるかで起動中かを判定する。
+func lockInstance(socketPath string) (func(), error) {
+	if conn, err := net.DialTimeout("unix", socketPath, dialTimeout); err == nil {
+		conn.Close()
+		return nil, ErrAlreadyRunning
+	}
+	return func() {}, nil
+}
+
+// checkPrivateDir は所有者を確かめられない環境では何もしない。
+func checkPrivateDir(string) error {
+	return nil
+}
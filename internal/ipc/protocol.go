@@ -0,0 +1,63 @@
+package ipc
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	CommandRefresh         = "refresh"
+	CommandShowPopover     = "show_popover"
+	CommandOpenBrainWindow = "open_brain_window"
+	CommandAddBrainNote    = "add_brain_note"
+	CommandGetCounts       = "get_counts"
+	// CommandForward は二重起動時に後発プロセスの引数を転送する。
+	CommandForward = "forward"
+)
+
+// Request はコントロールチャネルで受け付けるコマンド。
+type Request struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	Body    string   `json:"body,omitempty"`
+}
+
+// Response はコマンドの実行結果。
+type Response struct {
+	OK    bool   `json:"ok"`
+	Data  any    `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ParseArgs は CLI 引数（例: `refresh`, `add_brain_note 本文`）を Request に変換する。
+// 引数が空の場合はポップオーバー表示として扱う。
+func ParseArgs(args []string) (Request, error) {
+	if len(args) == 0 {
+		return Request{Command: CommandShowPopover}, nil
+	}
+	command := strings.TrimSpace(args[0])
+	rest := args[1:]
+	switch command {
+	case CommandRefresh, CommandShowPopover, CommandOpenBrainWindow, CommandGetCounts:
+		if len(rest) > 0 {
+			return Request{}, fmt.Errorf("%s takes no arguments", command)
+		}
+		return Request{Command: command}, nil
+	case CommandAddBrainNote:
+		body := strings.Join(rest, " ")
+		if strings.TrimSpace(body) == "" {
+			return Request{}, fmt.Errorf("%s requires a body", command)
+		}
+		return Request{Command: command, Body: body}, nil
+	default:
+		return Request{}, fmt.Errorf("unknown command: %s", command)
+	}
+}
+
+func okResponse(data any) Response {
+	return Response{OK: true, Data: data}
+}
+
+func errorResponse(err error) Response {
+	return Response{OK: false, Error: err.Error()}
+}
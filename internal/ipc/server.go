@@ -0,0 +1,101 @@
+package ipc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+const requestTimeout = 30 * time.Second
+
+// Handler は受信したコマンドを処理する。
+type Handler func(ctx context.Context, req Request) (any, error)
+
+// Server はコントロールチャネルの接続を 1 リクエスト 1 レスポンスで処理する。
+type Server struct {
+	Handler Handler
+	Logger  *slog.Logger
+
+	mu       sync.Mutex
+	listener net.Listener
+	closed   bool
+}
+
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	s.listener = ln
+	s.mu.Unlock()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return nil
+			}
+			return fmt.Errorf("accept: %w", err)
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.listener == nil {
+		return nil
+	}
+	s.closed = true
+	return s.listener.Close()
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		s.warn("read request failed", err)
+		return
+	}
+	resp := s.dispatch(line)
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		s.warn("write response failed", err)
+	}
+}
+
+func (s *Server) dispatch(line []byte) Response {
+	var req Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return errorResponse(fmt.Errorf("parse request: %w", err))
+	}
+	if req.Command == "" {
+		return errorResponse(errors.New("command is empty"))
+	}
+	if s.Handler == nil {
+		return errorResponse(errors.New("handler is not configured"))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	data, err := s.Handler(ctx, req)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return okResponse(data)
+}
+
+func (s *Server) warn(msg string, err error) {
+	if s.Logger != nil {
+		s.Logger.Warn(msg, "error", err)
+	}
+}
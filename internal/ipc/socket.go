@@ -0,0 +1,71 @@
+package ipc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const dialTimeout = time.Second
+
+// ErrAlreadyRunning は別プロセスがすでにソケットで待ち受けていることを表す。
+var ErrAlreadyRunning = errors.New("another instance is already running")
+
+// SocketPath はユーザー単位のソケットパスを返す。
+// XDG_RUNTIME_DIR があればその下、なければ一時ディレクトリの下のユーザー専用ディレクトリに置く。
+func SocketPath(appName string) string {
+	name := strings.ToLower(appName)
+	dir := strings.TrimSpace(os.Getenv("XDG_RUNTIME_DIR"))
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), fmt.Sprintf("%s-%d", name, os.Getuid()))
+	} else {
+		dir = filepath.Join(dir, name)
+	}
+	return filepath.Join(dir, name+".sock")
+}
+
+// Listen はソケットの待ち受けを開始する。
+// ソケットと同じディレクトリのロックファイルで起動中のプロセスを判定し、取れなければ ErrAlreadyRunning を返す。
+// ディレクトリは本人だけが入れる 0700 にするため、作った時点から他のユーザーはソケットに接続できない。
+func Listen(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("mkdir socket dir: %w", err)
+	}
+	if err := checkPrivateDir(dir); err != nil {
+		return nil, err
+	}
+	unlock, err := lockInstance(path)
+	if err != nil {
+		return nil, err
+	}
+	// ロックを取れたプロセスだけがここに来るため、残っているソケットは異常終了したプロセスのもの
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		unlock()
+		return nil, fmt.Errorf("remove stale socket: %w", err)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		unlock()
+		return nil, fmt.Errorf("listen socket: %w", err)
+	}
+	return &lockedListener{Listener: ln, unlock: unlock}, nil
+}
+
+// lockedListener は閉じたときに起動中のロックも解放する。
+type lockedListener struct {
+	net.Listener
+	unlock func()
+	once   sync.Once
+}
+
+func (l *lockedListener) Close() error {
+	err := l.Listener.Close()
+	l.once.Do(l.unlock)
+	return err
+}
@@ -0,0 +1,201 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+
+	coreapp "nudge/internal/app"
+)
+
+const maxMessageSize = 4 << 20
+
+// supportedProtocolVersions は応答できる MCP のバージョン（新しい順）。
+// 2025-03-26 は JSON-RPC のバッチを受け付ける必要があり、1 行 1 メッセージのこのサーバでは扱えないため含めない。
+var supportedProtocolVersions = []string{"2025-06-18", "2024-11-05"}
+
+// Server は stdio 上で Model Context Protocol を話し、Nudge のユースケースをツールとして公開する。
+type Server struct {
+	Name    string
+	Version string
+	Logger  *slog.Logger
+
+	tools   []tool
+	writeMu sync.Mutex
+}
+
+func NewServer(core *coreapp.App, name, version string) *Server {
+	return &Server{
+		Name:    name,
+		Version: version,
+		tools:   newTools(core),
+	}
+}
+
+// Serve は入力が閉じられるか ctx がキャンセルされるまで 1 行 1 メッセージで処理する。
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+	enc := json.NewEncoder(out)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.write(enc, response{
+				JSONRPC: jsonRPCVersion,
+				ID:      json.RawMessage("null"),
+				Error:   &rpcError{Code: codeParseError, Message: err.Error()},
+			})
+			continue
+		}
+		resp, ok := s.handle(ctx, req)
+		if !ok {
+			continue
+		}
+		s.write(enc, resp)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read stdin: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) write(enc *json.Encoder, resp response) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := enc.Encode(resp); err != nil && s.Logger != nil {
+		s.Logger.Warn("mcp write failed", "error", err)
+	}
+}
+
+// handle は 1 メッセージを処理する。通知には応答しないため false を返す。
+func (s *Server) handle(ctx context.Context, req request) (response, bool) {
+	if req.isNotification() {
+		return response{}, false
+	}
+	resp := response{JSONRPC: jsonRPCVersion, ID: req.ID}
+	if req.JSONRPC != jsonRPCVersion || req.Method == "" {
+		resp.Error = &rpcError{Code: codeInvalidRequest, Message: "invalid request"}
+		return resp, true
+	}
+	result, err := s.dispatch(ctx, req)
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: codeInternalError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp, true
+	}
+	resp.Result = result
+	return resp, true
+}
+
+func (s *Server) dispatch(ctx context.Context, req request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return s.listTools(), nil
+	case "tools/call":
+		return s.callTool(ctx, req.Params)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+func (s *Server) initialize(params json.RawMessage) (any, error) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+	}
+	// 対応するバージョンならそのまま返し、そうでなければ対応する最新を返してクライアントに判断させる
+	version := supportedProtocolVersions[0]
+	if slices.Contains(supportedProtocolVersions, p.ProtocolVersion) {
+		version = p.ProtocolVersion
+	}
+	return map[string]any{
+		"protocolVersion": version,
+		"capabilities": map[string]any{
+			"tools": map[string]any{},
+		},
+		"serverInfo": map[string]any{
+			"name":    s.Name,
+			"version": s.Version,
+		},
+	}, nil
+}
+
+func (s *Server) listTools() any {
+	out := make([]map[string]any, 0, len(s.tools))
+	for _, t := range s.tools {
+		out = append(out, map[string]any{
+			"name":        t.Name,
+			"description": t.Description,
+			"inputSchema": t.InputSchema,
+		})
+	}
+	return map[string]any{"tools": out}
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (any, error) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	var target *tool
+	for i := range s.tools {
+		if s.tools[i].Name == p.Name {
+			target = &s.tools[i]
+			break
+		}
+	}
+	if target == nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + p.Name}
+	}
+	args := p.Arguments
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+	// ツールの実行失敗はプロトコルエラーではなく isError 付きの結果として返す
+	data, err := target.Call(ctx, args)
+	if err != nil {
+		return toolResult(err.Error(), true), nil
+	}
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return toolResult(err.Error(), true), nil
+	}
+	return toolResult(string(b), false), nil
+}
+
+func toolResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{{
+			"type": "text",
+			"text": text,
+		}},
+		"isError": isError,
+	}
+}
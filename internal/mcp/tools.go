@@ -0,0 +1,197 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	coreapp "nudge/internal/app"
+	"nudge/internal/dto"
+	"nudge/internal/notion/notionid"
+)
+
+type tool struct {
+	Name        string
+	Description string
+	InputSchema map[string]any
+	Call        func(ctx context.Context, args json.RawMessage) (any, error)
+}
+
+// databaseTasks は DB 単位の取得結果。
+type databaseTasks struct {
+	DatabaseKey  string     `json:"database_key"`
+	DatabaseName string     `json:"database_name"`
+	Tasks        []dto.Task `json:"tasks"`
+}
+
+func newTools(core *coreapp.App) []tool {
+	return []tool{
+		{
+			Name:        "list_tasks",
+			Description: "List in-progress tasks from the configured Notion task databases. Omit database_key to list every enabled task database.",
+			InputSchema: objectSchema(map[string]any{
+				"database_key": stringProperty("Key of the task database in Nudge's config."),
+			}),
+			Call: func(ctx context.Context, args json.RawMessage) (any, error) {
+				var in struct {
+					DatabaseKey string `json:"database_key"`
+				}
+				if err := decodeArgs(args, &in); err != nil {
+					return nil, err
+				}
+				return listByKind(ctx, core, dto.DatabaseKindTask, in.DatabaseKey, func(ctx context.Context, key string) ([]dto.Task, error) {
+					return core.GetTasks(ctx, key, true)
+				})
+			},
+		},
+		{
+			Name:        "update_task_status",
+			Description: "Change the status of a task to done, paused, or back to in-progress (resume).",
+			InputSchema: objectSchema(map[string]any{
+				"database_key": stringProperty("Key of the task database the task belongs to."),
+				"task_id":      stringProperty("Notion page id or URL of the task."),
+				"action": map[string]any{
+					"type": "string",
+					"enum": []string{"done", "paused", "resume"},
+				},
+			}, "task_id", "action"),
+			Call: func(ctx context.Context, args json.RawMessage) (any, error) {
+				var in struct {
+					DatabaseKey string `json:"database_key"`
+					TaskID      string `json:"task_id"`
+					Action      string `json:"action"`
+				}
+				if err := decodeArgs(args, &in); err != nil {
+					return nil, err
+				}
+				taskID, err := notionid.Parse(in.TaskID)
+				if err != nil {
+					return nil, fmt.Errorf("task_id: %w", err)
+				}
+				in.TaskID = taskID
+				if err := core.UpdateTaskStatus(ctx, in.DatabaseKey, in.TaskID, in.Action); err != nil {
+					return nil, err
+				}
+				return map[string]any{"task_id": in.TaskID, "action": in.Action}, nil
+			},
+		},
+		{
+			Name:        "list_habits",
+			Description: "List today's unchecked habits. Omit database_key to list every enabled habit database.",
+			InputSchema: objectSchema(map[string]any{
+				"database_key": stringProperty("Key of the habit database in Nudge's config."),
+			}),
+			Call: func(ctx context.Context, args json.RawMessage) (any, error) {
+				var in struct {
+					DatabaseKey string `json:"database_key"`
+				}
+				if err := decodeArgs(args, &in); err != nil {
+					return nil, err
+				}
+				return listByKind(ctx, core, dto.DatabaseKindHabit, in.DatabaseKey, func(ctx context.Context, key string) ([]dto.Task, error) {
+					return core.GetHabits(ctx, key, true)
+				})
+			},
+		},
+		{
+			Name:        "check_habit",
+			Description: "Check (or uncheck) today's checkbox of a habit.",
+			InputSchema: objectSchema(map[string]any{
+				"database_key": stringProperty("Key of the habit database the habit belongs to."),
+				"task_id":      stringProperty("Notion page id or URL of the habit."),
+				"checked": map[string]any{
+					"type":    "boolean",
+					"default": true,
+				},
+			}, "task_id"),
+			Call: func(ctx context.Context, args json.RawMessage) (any, error) {
+				in := struct {
+					DatabaseKey string `json:"database_key"`
+					TaskID      string `json:"task_id"`
+					Checked     bool   `json:"checked"`
+				}{Checked: true}
+				if err := decodeArgs(args, &in); err != nil {
+					return nil, err
+				}
+				taskID, err := notionid.Parse(in.TaskID)
+				if err != nil {
+					return nil, fmt.Errorf("task_id: %w", err)
+				}
+				in.TaskID = taskID
+				if err := core.UpdateHabitCheck(ctx, in.DatabaseKey, in.TaskID, in.Checked); err != nil {
+					return nil, err
+				}
+				return map[string]any{"task_id": in.TaskID, "checked": in.Checked}, nil
+			},
+		},
+		{
+			Name:        "capture_brain_note",
+			Description: "Create a new page in a Brain capture profile's database from its template with the given Markdown body.",
+			InputSchema: objectSchema(map[string]any{
+				"body":    stringProperty("Body text of the note (Markdown)."),
+				"profile": stringProperty("Brain profile key. Omit to use the first profile."),
+			}, "body"),
+			Call: func(ctx context.Context, args json.RawMessage) (any, error) {
+				var in struct {
+					Body    string `json:"body"`
+					Profile string `json:"profile"`
+				}
+				if err := decodeArgs(args, &in); err != nil {
+					return nil, err
+				}
+				if strings.TrimSpace(in.Body) == "" {
+					return nil, fmt.Errorf("body is empty")
+				}
+				return core.CreateBrainPage(ctx, in.Profile, in.Body)
+			},
+		},
+	}
+}
+
+func listByKind(ctx context.Context, core *coreapp.App, kind, key string, fetch func(ctx context.Context, key string) ([]dto.Task, error)) ([]databaseTasks, error) {
+	cfg := core.GetConfig()
+	var out []databaseTasks
+	for _, db := range cfg.Databases {
+		if db.Kind != kind || !db.Enabled {
+			continue
+		}
+		if key != "" && db.Key != key {
+			continue
+		}
+		tasks, err := fetch(ctx, db.Key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", db.Key, err)
+		}
+		out = append(out, databaseTasks{DatabaseKey: db.Key, DatabaseName: db.Name, Tasks: tasks})
+	}
+	if key != "" && len(out) == 0 {
+		return nil, fmt.Errorf("database not found: %s", key)
+	}
+	return out, nil
+}
+
+func decodeArgs(args json.RawMessage, out any) error {
+	if err := json.Unmarshal(args, out); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProperty(description string) map[string]any {
+	return map[string]any{
+		"type":        "string",
+		"description": description,
+	}
+}
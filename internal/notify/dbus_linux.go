@@ -0,0 +1,54 @@
+//go:build linux
+
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	dbusDest   = "org.freedesktop.Notifications"
+	dbusPath   = "/org/freedesktop/Notifications"
+	dbusMethod = "org.freedesktop.Notifications.Notify"
+	// expireDefault はサーバ既定の表示時間に任せる。
+	expireDefault int32 = -1
+)
+
+// DBusNotifier は freedesktop の通知仕様（org.freedesktop.Notifications）で通知する。
+type DBusNotifier struct {
+	AppName string
+	conn    *dbus.Conn
+}
+
+func NewDBusNotifier(appName string) (*DBusNotifier, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("dbus session bus: %w", err)
+	}
+	return &DBusNotifier{AppName: appName, conn: conn}, nil
+}
+
+func (d *DBusNotifier) Notify(ctx context.Context, n Notification) error {
+	obj := d.conn.Object(dbusDest, dbus.ObjectPath(dbusPath))
+	hints := map[string]dbus.Variant{
+		"category": dbus.MakeVariant("x-nudge." + n.Kind),
+	}
+	call := obj.CallWithContext(ctx, dbusMethod, 0,
+		d.AppName, uint32(0), "", n.Title, n.Body, []string{}, hints, expireDefault)
+	if call.Err != nil {
+		return fmt.Errorf("dbus notify: %w", call.Err)
+	}
+	return nil
+}
+
+// NewSystemNotifier は OS 標準の通知手段を返す。セッションバスに繋がらない場合はログ出力にフォールバックする。
+func NewSystemNotifier(appName string) Notifier {
+	n, err := NewDBusNotifier(appName)
+	if err != nil {
+		return &LogNotifier{}
+	}
+	return n
+}
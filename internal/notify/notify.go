@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// 通知の種類。設定の有効/無効フラグと対応する。
+const (
+	KindTaskStarted   = "task_started"
+	KindTaskEdited    = "task_edited"
+	KindTaskAssigned  = "task_assigned"
+	KindHabitReminder = "habit_reminder"
+	KindSyncFailure   = "sync_failure"
+	KindTaskDue       = "task_due"
+	KindDueDigest     = "due_digest"
+	KindFocusDone     = "focus_done"
+	KindDailyReview   = "daily_review"
+	// KindActionFailed はトレイなど結果を表示する画面がない操作の失敗。設定に関わらず送る
+	KindActionFailed = "action_failed"
+)
+
+// Notification はデスクトップ通知 1 件分の内容。
+type Notification struct {
+	Kind  string
+	Title string
+	Body  string
+	// URL は通知に関連する Notion ページ（対応する実装のみ利用）。
+	URL string
+}
+
+// Notifier はデスクトップ通知の送信先。
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// LogNotifier は通知をログに出すだけの実装（テスト/非対応 OS 用）。送信内容は Sent で参照できる。
+type LogNotifier struct {
+	Logger *slog.Logger
+
+	mu   sync.Mutex
+	sent []Notification
+}
+
+func (l *LogNotifier) Notify(ctx context.Context, n Notification) error {
+	l.mu.Lock()
+	l.sent = append(l.sent, n)
+	l.mu.Unlock()
+	logger := l.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Info("notification", "kind", n.Kind, "title", n.Title, "body", n.Body)
+	return nil
+}
+
+// Sent はこれまでに送った通知の一覧を返す。
+func (l *LogNotifier) Sent() []Notification {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]Notification, len(l.sent))
+	copy(out, l.sent)
+	return out
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package notify
+
+// NewSystemNotifier は未対応 OS ではログ出力のみ行う。
+func NewSystemNotifier(appName string) Notifier {
+	return &LogNotifier{}
+}
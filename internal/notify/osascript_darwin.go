@@ -0,0 +1,35 @@
+//go:build darwin
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// OSAScriptNotifier は AppleScript の display notification で通知する。
+type OSAScriptNotifier struct {
+	AppName string
+}
+
+func (o *OSAScriptNotifier) Notify(ctx context.Context, n Notification) error {
+	script := fmt.Sprintf(`display notification "%s" with title "%s" subtitle "%s"`,
+		appleScriptString(n.Body), appleScriptString(o.AppName), appleScriptString(n.Title))
+	out, err := exec.CommandContext(ctx, "osascript", "-e", script).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("notify: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func appleScriptString(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return strings.ReplaceAll(value, `"`, `\"`)
+}
+
+// NewSystemNotifier は OS 標準の通知手段を返す。
+func NewSystemNotifier(appName string) Notifier {
+	return &OSAScriptNotifier{AppName: appName}
+}
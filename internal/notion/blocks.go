@@ -0,0 +1,167 @@
+package notion
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Block はページ作成・追記時に送るブロック 1 つ分の JSON。
+type Block map[string]any
+
+const (
+	// maxChildrenPerRequest は 1 回の API 呼び出しで送れる子ブロック数の上限。
+	maxChildrenPerRequest = 100
+	// maxRichTextLength は rich_text 要素 1 つの content の上限（文字数）。
+	maxRichTextLength = 2000
+	// maxRichTextItems は 1 ブロックの rich_text 配列の要素数の上限。
+	maxRichTextItems = 100
+)
+
+func Paragraph(content string) Block {
+	return textBlock("paragraph", plainRichText(content))
+}
+
+// Heading は level（1〜3）の見出しを返す。範囲外は 3 に丸める。
+func Heading(level int, content string) Block {
+	return textBlock(headingType(level), plainRichText(content))
+}
+
+func BulletedItem(content string) Block {
+	return textBlock("bulleted_list_item", plainRichText(content))
+}
+
+func ToDo(content string, checked bool) Block {
+	b := textBlock("to_do", plainRichText(content))
+	b.body()["checked"] = checked
+	return b
+}
+
+func Divider() Block {
+	return Block{"object": "block", "type": "divider", "divider": map[string]any{}}
+}
+
+func headingType(level int) string {
+	switch level {
+	case 1:
+		return "heading_1"
+	case 2:
+		return "heading_2"
+	default:
+		return "heading_3"
+	}
+}
+
+func textBlock(blockType string, rich []map[string]any) Block {
+	if rich == nil {
+		rich = []map[string]any{}
+	}
+	return Block{
+		"object": "block",
+		"type":   blockType,
+		blockType: map[string]any{
+			"rich_text": rich,
+		},
+	}
+}
+
+// plainRichText は装飾なしのテキストを上限ごとに分割した rich_text にする。
+func plainRichText(content string) []map[string]any {
+	return richTextItems([]inlineSpan{{Text: content}})
+}
+
+func (b Block) body() map[string]any {
+	blockType, _ := b["type"].(string)
+	body, _ := b[blockType].(map[string]any)
+	return body
+}
+
+func (b Block) children() []Block {
+	body := b.body()
+	if body == nil {
+		return nil
+	}
+	children, _ := body["children"].([]Block)
+	return children
+}
+
+// withChildren は子ブロックを差し替えた複製を返す。nil なら子を持たない。
+func (b Block) withChildren(children []Block) Block {
+	out := make(Block, len(b))
+	for k, v := range b {
+		out[k] = v
+	}
+	body := b.body()
+	if body == nil {
+		return out
+	}
+	copied := make(map[string]any, len(body))
+	for k, v := range body {
+		copied[k] = v
+	}
+	if len(children) > 0 {
+		copied["children"] = children
+	} else {
+		delete(copied, "children")
+	}
+	out[b["type"].(string)] = copied
+	return out
+}
+
+// splitNested は 1 回の呼び出しで送れる形にする。孫を持つ子や上限を超える子は外し、deferred に返す。
+func splitNested(blocks []Block) (inline []Block, deferred [][]Block, hasDeferred bool) {
+	inline = make([]Block, len(blocks))
+	deferred = make([][]Block, len(blocks))
+	for i, b := range blocks {
+		children := b.children()
+		if len(children) == 0 || canInline(children) {
+			inline[i] = b
+			continue
+		}
+		inline[i] = b.withChildren(nil)
+		deferred[i] = children
+		hasDeferred = true
+	}
+	return inline, deferred, hasDeferred
+}
+
+func canInline(children []Block) bool {
+	if len(children) > maxChildrenPerRequest {
+		return false
+	}
+	for _, child := range children {
+		if len(child.children()) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// AppendBlocks はブロックの末尾に blocks を追記する。上限を超える分や深い入れ子は複数回に分けて送る。
+func (c *Client) AppendBlocks(ctx context.Context, blockID string, blocks []Block, notionVersion string) error {
+	path := fmt.Sprintf("/v1/blocks/%s/children", blockID)
+	for len(blocks) > 0 {
+		n := min(len(blocks), maxChildrenPerRequest)
+		inline, deferred, hasDeferred := splitNested(blocks[:n])
+		var resp blocksResponse
+		if err := c.doJSON(ctx, http.MethodPatch, path, map[string]any{"children": inline}, &resp, notionVersion); err != nil {
+			return err
+		}
+		if hasDeferred {
+			// 応答の results は送った順に並ぶので、外した子を作成済みブロックの下に追記する
+			if len(resp.Results) < n {
+				return fmt.Errorf("append children: expected %d results, got %d", n, len(resp.Results))
+			}
+			for i, children := range deferred {
+				if len(children) == 0 {
+					continue
+				}
+				if err := c.AppendBlocks(ctx, resp.Results[i].ID, children, notionVersion); err != nil {
+					return err
+				}
+			}
+		}
+		blocks = blocks[n:]
+	}
+	return nil
+}
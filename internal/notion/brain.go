@@ -2,59 +2,97 @@ package notion
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
-	"net/url"
 	"strings"
 
 	"nudge/internal/dto"
 )
 
+// FetchTemplate はテンプレートのタイトルと本文を返す。本文は入れ子を字下げした Markdown 風のテキストで、
+// 差し込み位置（TemplateBodyPlaceholder）がある場合はテンプレート側に残る部分を含めず空にする。
 func (c *Client) FetchTemplate(ctx context.Context, pageID, notionVersion string) (dto.BrainTemplate, error) {
 	if strings.TrimSpace(pageID) == "" {
 		return dto.BrainTemplate{}, fmt.Errorf("template_page_id is required")
 	}
-	var p page
-	path := fmt.Sprintf("/v1/pages/%s", pageID)
-	if err := c.doJSON(ctx, http.MethodGet, path, nil, &p, notionVersion); err != nil {
+	tpl, _, err := c.fetchTemplatePage(ctx, pageID, notionVersion)
+	if err != nil {
 		return dto.BrainTemplate{}, err
 	}
-	blocks, err := c.listAllBlockChildren(ctx, pageID, notionVersion)
+	tree, err := c.fetchBlockTree(ctx, pageID, notionVersion)
 	if err != nil {
 		return dto.BrainTemplate{}, err
 	}
-	return dto.BrainTemplate{
-		Title: extractTitleFromProperties(p.Properties),
-		Body:  blocksToPlainText(blocks),
-	}, nil
+	out := dto.BrainTemplate{Title: extractTitleFromProperties(tpl.Properties)}
+	if hasPlaceholder(tree) {
+		out.HasPlaceholder = true
+		return out, nil
+	}
+	out.Body = strings.Join(templateText(tree, 0), "\n")
+	return out, nil
 }
 
-func (c *Client) CreatePageFromTemplate(ctx context.Context, databaseID, templatePageID, body, notionVersion string) (dto.CreatedPage, error) {
+// PageOptions はテンプレートから作るページの上書き指定。
+type PageOptions struct {
+	// Title を指定した場合はテンプレートのタイトルの代わりに使う。
+	Title string
+	// Properties はプロパティ名と値の文字列。テンプレートのプロパティの型に合わせて変換し、既定値を上書きする。
+	Properties map[string]string
+}
+
+func (c *Client) CreatePageFromTemplate(ctx context.Context, databaseID, templatePageID, body string, opts PageOptions, notionVersion string) (dto.CreatedPage, error) {
+	return c.CreatePageWithBlocks(ctx, databaseID, templatePageID, opts, MarkdownToBlocks(body), notionVersion)
+}
+
+// CreatePageWithBlocks はテンプレートのタイトル・アイコン・カバー・プロパティの既定値を引き継いでページを作成する。
+// テンプレートに差し込み位置があればテンプレートの本文を複製してその位置に blocks を入れ、なければ blocks だけを本文にする。
+func (c *Client) CreatePageWithBlocks(ctx context.Context, databaseID, templatePageID string, opts PageOptions, blocks []Block, notionVersion string) (dto.CreatedPage, error) {
 	if strings.TrimSpace(databaseID) == "" {
 		return dto.CreatedPage{}, fmt.Errorf("database_id is required")
 	}
 	if strings.TrimSpace(templatePageID) == "" {
 		return dto.CreatedPage{}, fmt.Errorf("template_page_id is required")
 	}
-	var tpl page
-	path := fmt.Sprintf("/v1/pages/%s", templatePageID)
-	if err := c.doJSON(ctx, http.MethodGet, path, nil, &tpl, notionVersion); err != nil {
+	tpl, rawProps, err := c.fetchTemplatePage(ctx, templatePageID, notionVersion)
+	if err != nil {
 		return dto.CreatedPage{}, err
 	}
-	titlePropertyName, title := extractTitleProperty(tpl.Properties)
+	titlePropertyName, tplTitle := extractTitleProperty(tpl.Properties)
 	if strings.TrimSpace(titlePropertyName) == "" {
 		return dto.CreatedPage{}, fmt.Errorf("title_property_name is required")
 	}
-	title = strings.TrimSpace(title)
+	title := strings.TrimSpace(opts.Title)
+	if title == "" {
+		title = strings.TrimSpace(tplTitle)
+	}
 	if title == "" {
 		title = "無題"
 	}
-	properties := map[string]any{
-		titlePropertyName: map[string]any{
-			"title": []map[string]any{{
-				"text": map[string]any{"content": title},
-			}},
-		},
+	tree, err := c.fetchBlockTree(ctx, templatePageID, notionVersion)
+	if err != nil {
+		return dto.CreatedPage{}, err
+	}
+	if hasPlaceholder(tree) {
+		blocks = cloneTemplate(tree, blocks)
+	}
+
+	properties := templateProperties(rawProps)
+	for name, value := range opts.Properties {
+		prop, ok := rawProps[name]
+		if !ok {
+			return dto.CreatedPage{}, fmt.Errorf("property %q not found in template", name)
+		}
+		converted, err := propertyFromString(prop, value)
+		if err != nil {
+			return dto.CreatedPage{}, fmt.Errorf("property %q: %w", name, err)
+		}
+		properties[name] = converted
+	}
+	properties[titlePropertyName] = map[string]any{
+		"title": []map[string]any{{
+			"text": map[string]any{"content": title},
+		}},
 	}
 	payload := map[string]any{
 		"parent":     map[string]any{"database_id": databaseID},
@@ -63,50 +101,54 @@ func (c *Client) CreatePageFromTemplate(ctx context.Context, databaseID, templat
 	if icon := buildPageIcon(tpl.Icon); icon != nil {
 		payload["icon"] = icon
 	}
-	body = strings.TrimRight(body, "\n")
-	if strings.TrimSpace(body) != "" {
-		payload["children"] = []map[string]any{
-			{
-				"object": "block",
-				"type":   "paragraph",
-				"paragraph": map[string]any{
-					"rich_text": []map[string]any{{
-						"type": "text",
-						"text": map[string]any{"content": body},
-					}},
-				},
-			},
-		}
+	if cover := buildPageCover(tpl.Cover); cover != nil {
+		payload["cover"] = cover
+	}
+	// 作成時にまとめて送れない場合（上限超過・深い入れ子）は作成後に追記する
+	first, _, hasDeferred := splitNested(blocks[:min(len(blocks), maxChildrenPerRequest)])
+	if hasDeferred {
+		first = nil
+	}
+	if len(first) > 0 {
+		payload["children"] = first
 	}
 	var resp page
 	if err := c.doJSON(ctx, http.MethodPost, "/v1/pages", payload, &resp, notionVersion); err != nil {
 		return dto.CreatedPage{}, err
 	}
-	return dto.CreatedPage{ID: resp.ID, URL: resp.URL}, nil
+	created := dto.CreatedPage{ID: resp.ID, URL: resp.URL}
+	if err := c.AppendBlocks(ctx, resp.ID, blocks[len(first):], notionVersion); err != nil {
+		return created, err
+	}
+	return created, nil
 }
 
-func (c *Client) listAllBlockChildren(ctx context.Context, blockID, notionVersion string) ([]block, error) {
-	if strings.TrimSpace(blockID) == "" {
-		return nil, fmt.Errorf("block_id is required")
-	}
-	var out []block
-	cursor := ""
-	for {
-		path := fmt.Sprintf("/v1/blocks/%s/children?page_size=100", blockID)
-		if cursor != "" {
-			path = path + "&start_cursor=" + url.QueryEscape(cursor)
-		}
-		var resp blocksResponse
-		if err := c.doJSON(ctx, http.MethodGet, path, nil, &resp, notionVersion); err != nil {
-			return nil, err
-		}
-		out = append(out, resp.Results...)
-		if !resp.HasMore || resp.NextCursor == "" {
-			break
-		}
-		cursor = resp.NextCursor
+// fetchTemplatePage はテンプレートページを取得し、型付きの内容と生のプロパティを返す。
+func (c *Client) fetchTemplatePage(ctx context.Context, pageID, notionVersion string) (page, map[string]map[string]any, error) {
+	var raw json.RawMessage
+	path := fmt.Sprintf("/v1/pages/%s", pageID)
+	if err := c.doJSON(ctx, http.MethodGet, path, nil, &raw, notionVersion); err != nil {
+		return page{}, nil, err
 	}
-	return out, nil
+	var p page
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return page{}, nil, fmt.Errorf("decode template: %w", err)
+	}
+	var props struct {
+		Properties map[string]map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal(raw, &props); err != nil {
+		return page{}, nil, fmt.Errorf("decode template: %w", err)
+	}
+	return p, props.Properties, nil
+}
+
+// buildPageCover はテンプレートのカバー画像を作成用の形にする。
+func buildPageCover(cover *pageIcon) map[string]any {
+	if cover == nil || cover.Type == "emoji" {
+		return nil
+	}
+	return buildPageIcon(cover)
 }
 
 func buildPageIcon(icon *pageIcon) map[string]any {
@@ -20,6 +20,12 @@ const (
 	defaultBaseURL = "https://api.notion.com"
 )
 
+// ErrTokenNotSet はトークンが未設定のまま API を呼んだことを表す。
+var ErrTokenNotSet = errors.New("notion token is not set")
+
+// ErrUnauthorized はトークンが Notion に拒否された（401）ことを表す。失効・取り消し・入力誤りのいずれか。
+var ErrUnauthorized = errors.New("notion token is invalid")
+
 type Client struct {
 	httpClient *http.Client
 	baseURL    string
@@ -60,6 +66,18 @@ func NewClient(tokenStore store.TokenStore, opts ...Option) *Client {
 	return c
 }
 
+// ForAccount は account のトークンで API を呼ぶクライアントを返す。空のとき、
+// またはトークンストアがアカウントを分けられないときは c をそのまま返す。
+func (c *Client) ForAccount(account string) *Client {
+	accounts, ok := c.tokenStore.(store.AccountTokenStore)
+	if account == "" || !ok {
+		return c
+	}
+	clone := *c
+	clone.tokenStore = accounts.ForAccount(account)
+	return &clone
+}
+
 func (c *Client) QueryInProgress(ctx context.Context, db dto.DatabaseConfig, notionVersion string, maxResults int) ([]dto.Task, error) {
 	return c.QueryByStatus(ctx, db, notionVersion, maxResults, db.StatusInProgress)
 }
@@ -83,7 +101,7 @@ func (c *Client) QueryByStatus(ctx context.Context, db dto.DatabaseConfig, notio
 	if err := c.doJSON(ctx, http.MethodPost, path, body, &resp, notionVersion); err != nil {
 		return nil, err
 	}
-	return mapTasks(resp.Results, db.TitlePropertyName, db.StatusPropertyName, ""), nil
+	return mapTasks(resp.Results, db.TitlePropertyName, db.StatusPropertyName, "", db.DuePropertyName, db.AssigneePropertyName), nil
 }
 
 func (c *Client) UpdateStatus(ctx context.Context, pageID string, db dto.DatabaseConfig, notionVersion string, statusValue string) error {
@@ -120,7 +138,7 @@ func (c *Client) QueryHabitsToday(ctx context.Context, db dto.DatabaseConfig, ch
 	if err := c.doJSON(ctx, http.MethodPost, path, body, &resp, notionVersion); err != nil {
 		return nil, err
 	}
-	return mapTasks(resp.Results, db.TitlePropertyName, "", checkboxPropertyName), nil
+	return mapTasks(resp.Results, db.TitlePropertyName, "", checkboxPropertyName, "", ""), nil
 }
 
 func (c *Client) UpdateCheckbox(ctx context.Context, pageID string, db dto.DatabaseConfig, checkboxPropertyName string, notionVersion string, checked bool) error {
@@ -201,16 +219,43 @@ func buildStatusUpdate(typ, value string) map[string]any {
 }
 
 func (c *Client) doJSON(ctx context.Context, method, path string, body any, out any, notionVersion string) error {
+	token, err := c.token()
+	if err != nil {
+		return err
+	}
+	err = c.doJSONWithToken(ctx, token, method, path, body, out, notionVersion)
+	if !errors.Is(err, ErrUnauthorized) {
+		return err
+	}
+	// キャッシュしたトークンが古い（別のプロセスで保存し直した）場合に備え、読み直して変わっていれば 1 度だけやり直す
+	invalidator, ok := c.tokenStore.(store.TokenInvalidator)
+	if !ok {
+		return err
+	}
+	invalidator.InvalidateToken()
+	fresh, freshErr := c.token()
+	if freshErr != nil || fresh == token {
+		return err
+	}
+	return c.doJSONWithToken(ctx, fresh, method, path, body, out, notionVersion)
+}
+
+func (c *Client) token() (string, error) {
 	token, err := c.tokenStore.GetToken()
 	if err != nil {
 		if errors.Is(err, store.ErrTokenNotFound) {
-			return errors.New("notion token is not set")
+			return "", ErrTokenNotSet
 		}
-		return err
+		return "", err
 	}
 	if token == "" {
-		return errors.New("notion token is empty")
+		return "", errors.New("notion token is empty")
 	}
+	return token, nil
+}
+
+// doJSONWithToken はトークンストアを使わず、渡したトークンで API を呼ぶ。
+func (c *Client) doJSONWithToken(ctx context.Context, token, method, path string, body any, out any, notionVersion string) error {
 	if notionVersion == "" {
 		notionVersion = c.version
 	}
@@ -267,6 +312,9 @@ func (c *Client) doJSON(ctx context.Context, method, path string, body any, out
 
 		b, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized {
+			return fmt.Errorf("%w: %s", ErrUnauthorized, strings.TrimSpace(string(b)))
+		}
 		if shouldRetry(resp.StatusCode) && attempt < c.maxRetries {
 			wait := c.retryWait
 			if resp.StatusCode == http.StatusTooManyRequests {
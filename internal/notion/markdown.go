@@ -0,0 +1,388 @@
+package notion
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	mdHeading  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	mdListItem = regexp.MustCompile(`^([-*+]|\d+[.)])\s+(.*)$`)
+	mdToDo     = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+	mdFence    = regexp.MustCompile("^(```+|~~~+)\\s*([^`\\s]*)")
+	mdDivider  = regexp.MustCompile(`^(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+)
+
+// codeLanguages は Notion の code ブロックが受け付ける言語名（よく使うもの）と別名。
+var codeLanguages = map[string]string{
+	"bash": "bash", "sh": "shell", "shell": "shell", "zsh": "shell",
+	"c": "c", "cpp": "c++", "c++": "c++", "cs": "c#", "csharp": "c#", "c#": "c#",
+	"css": "css", "diff": "diff", "docker": "docker", "dockerfile": "docker",
+	"go": "go", "golang": "go", "graphql": "graphql", "html": "html",
+	"java": "java", "javascript": "javascript", "js": "javascript",
+	"json": "json", "kotlin": "kotlin", "kt": "kotlin", "makefile": "makefile",
+	"markdown": "markdown", "md": "markdown", "mermaid": "mermaid",
+	"php": "php", "powershell": "powershell", "ps1": "powershell",
+	"python": "python", "py": "python", "ruby": "ruby", "rb": "ruby",
+	"rust": "rust", "rs": "rust", "scss": "scss", "sql": "sql",
+	"swift": "swift", "toml": "toml", "typescript": "typescript", "ts": "typescript",
+	"xml": "xml", "yaml": "yaml", "yml": "yaml",
+}
+
+// mdNode は変換途中のブロック。リスト項目は children に入れ子を持つ。
+type mdNode struct {
+	blockType string
+	text      string
+	checked   bool
+	language  string
+	indent    int
+	children  []*mdNode
+}
+
+// MarkdownToBlocks は Markdown を Notion のブロック列に変換する。
+// 見出し・箇条書き/番号付きリスト（入れ子）・ToDo・引用・コード・区切り線・段落と、
+// 太字/斜体/インラインコード/リンクの装飾に対応する。
+func MarkdownToBlocks(src string) []Block {
+	p := &mdParser{}
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		i = p.line(lines, i)
+	}
+	p.flush()
+	out := make([]Block, 0, len(p.out))
+	for _, n := range p.out {
+		out = append(out, n.blocks()...)
+	}
+	return out
+}
+
+type mdParser struct {
+	out   []*mdNode
+	list  []*mdNode // 開いているリスト項目（外側から順）
+	para  []string
+	quote []string
+}
+
+// line は lines[i] を処理し、最後に消費した行の位置を返す（コードブロックは複数行を消費する）。
+func (p *mdParser) line(lines []string, i int) int {
+	raw := strings.TrimRight(lines[i], " \t")
+	indent := indentWidth(raw)
+	text := strings.TrimSpace(raw)
+
+	if text == "" {
+		p.flush()
+		return i
+	}
+	if m := mdFence.FindStringSubmatch(text); m != nil {
+		p.flush()
+		p.list = nil
+		var body []string
+		j := i + 1
+		for ; j < len(lines); j++ {
+			if strings.HasPrefix(strings.TrimSpace(lines[j]), m[1]) {
+				break
+			}
+			body = append(body, lines[j])
+		}
+		p.out = append(p.out, &mdNode{blockType: "code", text: strings.Join(body, "\n"), language: codeLanguage(m[2])})
+		return j
+	}
+	if m := mdListItem.FindStringSubmatch(text); m != nil && !mdDivider.MatchString(text) {
+		p.flush()
+		p.listItem(indent, m[1], m[2])
+		return i
+	}
+	// リスト項目の下に字下げされた行は項目の続きとして扱う
+	if len(p.list) > 0 && len(p.para) == 0 && indent > p.list[len(p.list)-1].indent {
+		top := p.list[len(p.list)-1]
+		top.text += "\n" + text
+		return i
+	}
+	p.list = nil
+
+	switch {
+	case mdDivider.MatchString(text):
+		p.flush()
+		p.out = append(p.out, &mdNode{blockType: "divider"})
+	case mdHeading.MatchString(text):
+		p.flush()
+		m := mdHeading.FindStringSubmatch(text)
+		p.out = append(p.out, &mdNode{blockType: headingType(len(m[1])), text: m[2]})
+	case strings.HasPrefix(text, ">"):
+		if len(p.para) > 0 {
+			p.flush()
+		}
+		p.quote = append(p.quote, strings.TrimSpace(strings.TrimPrefix(text, ">")))
+	default:
+		if len(p.quote) > 0 {
+			p.flush()
+		}
+		p.para = append(p.para, text)
+	}
+	return i
+}
+
+func (p *mdParser) listItem(indent int, marker, text string) {
+	node := &mdNode{blockType: "bulleted_list_item", text: text, indent: indent}
+	if marker[0] >= '0' && marker[0] <= '9' {
+		node.blockType = "numbered_list_item"
+	}
+	if m := mdToDo.FindStringSubmatch(text); m != nil {
+		node.blockType = "to_do"
+		node.checked = m[1] != " "
+		node.text = m[2]
+	}
+	for len(p.list) > 0 && p.list[len(p.list)-1].indent >= indent {
+		p.list = p.list[:len(p.list)-1]
+	}
+	if len(p.list) == 0 {
+		p.out = append(p.out, node)
+	} else {
+		parent := p.list[len(p.list)-1]
+		parent.children = append(parent.children, node)
+	}
+	p.list = append(p.list, node)
+}
+
+// flush は溜めている段落・引用をブロックにする。
+func (p *mdParser) flush() {
+	if len(p.para) > 0 {
+		p.out = append(p.out, &mdNode{blockType: "paragraph", text: strings.Join(p.para, "\n")})
+		p.para = nil
+	}
+	if len(p.quote) > 0 {
+		p.out = append(p.out, &mdNode{blockType: "quote", text: strings.Join(p.quote, "\n")})
+		p.quote = nil
+	}
+}
+
+// blocks はノードをブロックにする。rich_text の要素数が上限を超える場合は同じ種類のブロックに分ける。
+func (n *mdNode) blocks() []Block {
+	if n.blockType == "divider" {
+		return []Block{Divider()}
+	}
+	var rich []map[string]any
+	if n.blockType == "code" {
+		rich = plainRichText(n.text)
+	} else {
+		rich = richTextItems(parseInline(n.text))
+	}
+	var out []Block
+	for len(out) == 0 || len(rich) > 0 {
+		chunk := rich[:min(len(rich), maxRichTextItems)]
+		rich = rich[len(chunk):]
+		b := textBlock(n.blockType, chunk)
+		switch n.blockType {
+		case "to_do":
+			b.body()["checked"] = n.checked
+		case "code":
+			b.body()["language"] = n.language
+		}
+		out = append(out, b)
+	}
+	if len(n.children) > 0 {
+		var children []Block
+		for _, child := range n.children {
+			children = append(children, child.blocks()...)
+		}
+		out[0] = out[0].withChildren(children)
+	}
+	return out
+}
+
+func indentWidth(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+func codeLanguage(info string) string {
+	if lang, ok := codeLanguages[strings.ToLower(info)]; ok {
+		return lang
+	}
+	return "plain text"
+}
+
+// inlineSpan は同じ装飾が続くテキストの区間。
+type inlineSpan struct {
+	Text   string
+	Bold   bool
+	Italic bool
+	Code   bool
+	Link   string
+}
+
+// parseInline は **太字** / __太字__、*斜体* / _斜体_、`コード`、[テキスト](URL) を解釈する。
+// 閉じ記号のない記号やバックスラッシュでエスケープした記号は文字として残す。
+func parseInline(src string) []inlineSpan {
+	return parseInlineWith(src, inlineSpan{})
+}
+
+// parseInlineWith は base の装飾を引き継いで解釈する（リンクのラベルに使う）。
+func parseInlineWith(src string, base inlineSpan) []inlineSpan {
+	var spans []inlineSpan
+	var buf strings.Builder
+	bold, italic := base.Bold, base.Italic
+	emit := func() {
+		if buf.Len() == 0 {
+			return
+		}
+		spans = appendSpan(spans, inlineSpan{Text: buf.String(), Bold: bold, Italic: italic, Link: base.Link})
+		buf.Reset()
+	}
+	for i := 0; i < len(src); {
+		rest := src[i:]
+		c := src[i]
+		switch {
+		case c == '\\' && i+1 < len(src) && strings.IndexByte("\\`*_[]()#>-+!~", src[i+1]) >= 0:
+			buf.WriteByte(src[i+1])
+			i += 2
+			continue
+		case c == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end > 0 {
+				emit()
+				spans = appendSpan(spans, inlineSpan{Text: rest[1 : 1+end], Bold: bold, Italic: italic, Code: true, Link: base.Link})
+				i += end + 2
+				continue
+			}
+		case c == '[' && base.Link == "":
+			if label, url, width, ok := parseLink(rest); ok {
+				emit()
+				inner := inlineSpan{Bold: bold, Italic: italic}
+				if isLinkable(url) {
+					inner.Link = url
+				}
+				for _, span := range parseInlineWith(label, inner) {
+					spans = appendSpan(spans, span)
+				}
+				i += width
+				continue
+			}
+		case (c == '*' || c == '_') && strings.HasPrefix(rest, strings.Repeat(string(c), 2)):
+			if bold || (strings.Contains(rest[2:], rest[:2]) && (c == '*' || canOpenUnderscore(src, i))) {
+				emit()
+				bold = !bold
+				i += 2
+				continue
+			}
+		case c == '*' || c == '_':
+			opening := !italic && strings.IndexByte(rest[1:], c) >= 0 && (c == '*' || canOpenUnderscore(src, i))
+			closing := italic && (c == '*' || canCloseUnderscore(src, i))
+			if opening || closing {
+				emit()
+				italic = !italic
+				i++
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(rest)
+		buf.WriteString(rest[:size])
+		i += size
+	}
+	emit()
+	return spans
+}
+
+// parseLink は "[label](url)" を読み取る。
+func parseLink(s string) (label, url string, width int, ok bool) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth != 0 {
+				continue
+			}
+			if i+1 >= len(s) || s[i+1] != '(' {
+				return "", "", 0, false
+			}
+			end := strings.IndexByte(s[i+2:], ')')
+			if end < 0 {
+				return "", "", 0, false
+			}
+			url = s[i+2 : i+2+end]
+			if strings.ContainsAny(url, " \t\n") {
+				return "", "", 0, false
+			}
+			return s[1:i], url, i + 2 + end + 1, true
+		}
+	}
+	return "", "", 0, false
+}
+
+// isLinkable は Notion が受け付ける URL かを返す（相対パスなどはリンクにしない）。
+func isLinkable(url string) bool {
+	for _, scheme := range []string{"http://", "https://", "mailto:"} {
+		if strings.HasPrefix(strings.ToLower(url), scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// canOpenUnderscore / canCloseUnderscore は snake_case の "_" を強調として扱わないための判定。
+func canOpenUnderscore(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func canCloseUnderscore(s string, i int) bool {
+	j := i + 1
+	for j < len(s) && s[j] == '_' {
+		j++
+	}
+	if j >= len(s) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(s[j:])
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func appendSpan(spans []inlineSpan, s inlineSpan) []inlineSpan {
+	if n := len(spans); n > 0 {
+		last := &spans[n-1]
+		if last.Bold == s.Bold && last.Italic == s.Italic && last.Code == s.Code && last.Link == s.Link {
+			last.Text += s.Text
+			return spans
+		}
+	}
+	return append(spans, s)
+}
+
+// richTextItems は区間を rich_text の要素にする。1 要素の content は上限の文字数ごとに分ける。
+func richTextItems(spans []inlineSpan) []map[string]any {
+	out := make([]map[string]any, 0, len(spans))
+	for _, s := range spans {
+		runes := []rune(s.Text)
+		for len(runes) > 0 {
+			n := min(len(runes), maxRichTextLength)
+			text := map[string]any{"content": string(runes[:n])}
+			if s.Link != "" {
+				text["link"] = map[string]any{"url": s.Link}
+			}
+			item := map[string]any{"type": "text", "text": text}
+			if s.Bold || s.Italic || s.Code {
+				item["annotations"] = map[string]any{"bold": s.Bold, "italic": s.Italic, "code": s.Code}
+			}
+			out = append(out, item)
+			runes = runes[n:]
+		}
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+// Package notionid は Notion の共有 URL やハイフン付き UUID からページ / データベースの ID を取り出す。
+// dto からも使うため、他の内部パッケージには依存しない。
+package notionid
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalid は値から Notion の ID を取り出せなかったことを表す。
+var ErrInvalid = errors.New("invalid notion id")
+
+const idLength = 32
+
+// Parse は ID または URL から ID を取り出し、ハイフンなし 32 桁の小文字に揃えて返す。
+// 受け付ける形式:
+//   - 0123456789abcdef0123456789abcdef
+//   - 01234567-89ab-cdef-0123-456789abcdef
+//   - https://www.notion.so/<workspace>/<slug>-<id>?v=<view>（共有リンク・DB のビューを含む）
+//   - https://<workspace>.notion.site/<slug>-<id>
+//
+// URL はパスの最後の要素から ID を読む。クエリの v（ビュー）や p（プレビュー中のページ）は使わない。
+func Parse(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if id, ok := parseID(value); ok {
+		return id, nil
+	}
+	if id, ok := parseURL(value); ok {
+		return id, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalid, value)
+}
+
+// Normalize は Parse できれば正規化した ID を、できなければ前後の空白を除いた元の値を返す。
+// 設定の正規化で使い、不正な値は検証で項目ごとに報告する。
+func Normalize(value string) string {
+	if id, err := Parse(value); err == nil {
+		return id
+	}
+	return strings.TrimSpace(value)
+}
+
+// Valid は値から ID を取り出せるかを返す。
+func Valid(value string) bool {
+	_, err := Parse(value)
+	return err == nil
+}
+
+// parseID はハイフンなし / ハイフン付き UUID 形式の ID を読む。
+func parseID(value string) (string, bool) {
+	if len(value) == idLength+4 {
+		if value[8] != '-' || value[13] != '-' || value[18] != '-' || value[23] != '-' {
+			return "", false
+		}
+		value = strings.ReplaceAll(value, "-", "")
+	}
+	if len(value) != idLength || !isHex(value) {
+		return "", false
+	}
+	return strings.ToLower(value), true
+}
+
+func parseURL(value string) (string, bool) {
+	if !strings.Contains(value, "://") {
+		// スキーム抜きでコピーされた notion.so/... も受け付ける
+		value = "https://" + value
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !isNotionHost(u.Hostname()) {
+		return "", false
+	}
+	path := strings.TrimRight(u.Path, "/")
+	last := path[strings.LastIndex(path, "/")+1:]
+	if id, ok := parseID(last); ok {
+		return id, true
+	}
+	// <slug>-<id> 形式。スラッグ自体にハイフンを含むことがあるため末尾の 32 桁を見る
+	if len(last) > idLength && last[len(last)-idLength-1] == '-' {
+		return parseID(last[len(last)-idLength:])
+	}
+	return "", false
+}
+
+func isNotionHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range []string{"notion.so", "notion.site"} {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'f', r >= 'A' && r <= 'F':
+		default:
+			return false
+		}
+	}
+	return true
+}
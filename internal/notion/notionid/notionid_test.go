@@ -0,0 +1,54 @@
+package notionid
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	const want = "0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"32 hex", "0123456789abcdef0123456789abcdef"},
+		{"32 hex upper case with spaces", "  0123456789ABCDEF0123456789ABCDEF\n"},
+		{"dashed uuid", "01234567-89ab-cdef-0123-456789abcdef"},
+		{"notion.so url", "https://www.notion.so/0123456789abcdef0123456789abcdef"},
+		{"notion.so url with workspace and slug", "https://www.notion.so/acme/Weekly-Tasks-0123456789abcdef0123456789abcdef"},
+		{"notion.so url with view query", "https://www.notion.so/acme/0123456789abcdef0123456789abcdef?v=fedcba9876543210fedcba9876543210"},
+		{"notion.so url with fragment", "https://www.notion.so/Tasks-0123456789abcdef0123456789abcdef#fedcba9876543210fedcba9876543210"},
+		{"notion.so url with trailing slash", "https://notion.so/Tasks-0123456789abcdef0123456789abcdef/"},
+		{"url without scheme", "notion.so/acme/Tasks-0123456789abcdef0123456789abcdef"},
+		{"notion.site url", "https://acme.notion.site/Public-Page-0123456789abcdef0123456789abcdef?pvs=4"},
+		{"notion.site url with dashed uuid", "https://acme.notion.site/01234567-89ab-cdef-0123-456789abcdef"},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.value)
+		if err != nil {
+			t.Errorf("%s: Parse(%q) error = %v", tt.name, tt.value, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s: Parse(%q) = %q, want %q", tt.name, tt.value, got, want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"0123456789abcdef",
+		"0123456789abcdef0123456789abcdeg",
+		"0123456789abcdef0123456789abcdef0",
+		"01234567-89ab-cdef-0123456789abcdef",
+		"https://example.com/Tasks-0123456789abcdef0123456789abcdef",
+		"https://www.notion.so/acme/Tasks",
+		"ftp://www.notion.so/0123456789abcdef0123456789abcdef",
+		"https://www.notion.so/Tasks0123456789abcdef0123456789abcdef",
+	} {
+		if got, err := Parse(value); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) = %q, %v, want ErrInvalid", value, got, err)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package notion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// TemplateBodyPlaceholder を本文に持つブロックがテンプレートにあれば、その位置に入力した本文を差し込む。
+const TemplateBodyPlaceholder = "{{body}}"
+
+// templateNode はテンプレートのブロック 1 つと、その子ブロック。
+type templateNode struct {
+	block    block
+	raw      Block
+	children []templateNode
+}
+
+type rawBlocksResponse struct {
+	Results    []json.RawMessage `json:"results"`
+	HasMore    bool              `json:"has_more"`
+	NextCursor string            `json:"next_cursor"`
+}
+
+// fetchBlockTree は has_children のブロックをたどってブロックの木を取得する。子ページ・子 DB の中身はたどらない。
+func (c *Client) fetchBlockTree(ctx context.Context, blockID, notionVersion string) ([]templateNode, error) {
+	var out []templateNode
+	cursor := ""
+	for {
+		path := fmt.Sprintf("/v1/blocks/%s/children?page_size=100", blockID)
+		if cursor != "" {
+			path = path + "&start_cursor=" + url.QueryEscape(cursor)
+		}
+		var resp rawBlocksResponse
+		if err := c.doJSON(ctx, http.MethodGet, path, nil, &resp, notionVersion); err != nil {
+			return nil, err
+		}
+		for _, msg := range resp.Results {
+			var node templateNode
+			if err := json.Unmarshal(msg, &node.block); err != nil {
+				return nil, fmt.Errorf("decode block: %w", err)
+			}
+			if err := json.Unmarshal(msg, &node.raw); err != nil {
+				return nil, fmt.Errorf("decode block: %w", err)
+			}
+			if node.block.HasChildren && node.block.Type != "child_page" && node.block.Type != "child_database" {
+				children, err := c.fetchBlockTree(ctx, node.block.ID, notionVersion)
+				if err != nil {
+					return nil, err
+				}
+				node.children = children
+			}
+			out = append(out, node)
+		}
+		if !resp.HasMore || resp.NextCursor == "" {
+			break
+		}
+		cursor = resp.NextCursor
+	}
+	return out, nil
+}
+
+// hasPlaceholder はブロックの木に本文の差し込み位置があるかを返す。
+func hasPlaceholder(nodes []templateNode) bool {
+	for _, n := range nodes {
+		if isPlaceholder(n) || hasPlaceholder(n.children) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPlaceholder(n templateNode) bool {
+	return extractBlockText(n.block) == TemplateBodyPlaceholder
+}
+
+// templateText はブロックの木を Markdown 風のテキストにする。子ブロックは 2 文字ずつ字下げする。
+func templateText(nodes []templateNode, depth int) []string {
+	var lines []string
+	for _, n := range nodes {
+		if line := blockToLine(n.block); line != "" {
+			lines = append(lines, strings.Repeat("  ", depth)+line)
+		}
+		lines = append(lines, templateText(n.children, depth+1)...)
+	}
+	return lines
+}
+
+// cloneTemplate はテンプレートのブロックの木を作成用に複製し、差し込み位置を body に置き換える。
+func cloneTemplate(nodes []templateNode, body []Block) []Block {
+	var out []Block
+	for _, n := range nodes {
+		if isPlaceholder(n) {
+			out = append(out, body...)
+			continue
+		}
+		children := cloneTemplate(n.children, body)
+		switch n.block.Type {
+		case "column_list", "column", "synced_block":
+			// 列や同期ブロックは子と同時に作る必要があり分割して送れないため、中身だけを並べる
+			out = append(out, children...)
+			continue
+		}
+		b, ok := sanitizeBlock(n.raw)
+		if !ok {
+			continue
+		}
+		out = append(out, b.withChildren(children))
+	}
+	return out
+}
+
+// sanitizeBlock は取得したブロックから作成時に送れる部分だけを残す。作成できない種類は ok=false。
+func sanitizeBlock(raw Block) (Block, bool) {
+	blockType, _ := raw["type"].(string)
+	body, _ := raw[blockType].(map[string]any)
+	if blockType == "" || body == nil {
+		return nil, false
+	}
+	switch blockType {
+	case "child_page", "child_database", "unsupported", "link_preview", "template":
+		return nil, false
+	case "image", "file", "pdf", "video", "audio":
+		// Notion にアップロードされたファイルの URL は期限付きのため、外部 URL のものだけ複製する
+		if body["type"] != "external" {
+			return nil, false
+		}
+	}
+	copied := make(map[string]any, len(body))
+	for k, v := range body {
+		switch k {
+		case "rich_text", "caption":
+			copied[k] = cleanRichText(v)
+		default:
+			copied[k] = v
+		}
+	}
+	return Block{"object": "block", "type": blockType, blockType: copied}, true
+}
+
+// cleanRichText は取得時にだけ付く plain_text / href を rich_text の各要素から除く。
+func cleanRichText(value any) any {
+	items, ok := value.([]any)
+	if !ok {
+		return value
+	}
+	out := make([]any, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		copied := make(map[string]any, len(m))
+		for k, v := range m {
+			if k != "plain_text" && k != "href" {
+				copied[k] = v
+			}
+		}
+		out = append(out, copied)
+	}
+	return out
+}
+
+// templateProperties はテンプレートの既定値のうち作成時に指定できるプロパティを返す。タイトルは含めない。
+func templateProperties(props map[string]map[string]any) map[string]any {
+	out := make(map[string]any)
+	for name, prop := range props {
+		propType, _ := prop["type"].(string)
+		value, ok := prop[propType]
+		if !ok || value == nil {
+			continue
+		}
+		switch propType {
+		case "select", "status":
+			option, _ := value.(map[string]any)
+			if option == nil || option["name"] == nil {
+				continue
+			}
+			out[name] = map[string]any{propType: map[string]any{"name": option["name"]}}
+		case "multi_select":
+			items, _ := value.([]any)
+			names := make([]map[string]any, 0, len(items))
+			for _, item := range items {
+				if option, ok := item.(map[string]any); ok && option["name"] != nil {
+					names = append(names, map[string]any{"name": option["name"]})
+				}
+			}
+			if len(names) > 0 {
+				out[name] = map[string]any{propType: names}
+			}
+		case "relation", "people":
+			items, _ := value.([]any)
+			ids := make([]map[string]any, 0, len(items))
+			for _, item := range items {
+				if ref, ok := item.(map[string]any); ok && ref["id"] != nil {
+					ids = append(ids, map[string]any{"id": ref["id"]})
+				}
+			}
+			if len(ids) > 0 {
+				out[name] = map[string]any{propType: ids}
+			}
+		case "rich_text":
+			if items, _ := value.([]any); len(items) > 0 {
+				out[name] = map[string]any{propType: cleanRichText(items)}
+			}
+		case "date", "checkbox", "number", "url", "email", "phone_number":
+			out[name] = map[string]any{propType: value}
+		case "files":
+			items, _ := value.([]any)
+			var files []any
+			for _, item := range items {
+				if f, ok := item.(map[string]any); ok && f["type"] == "external" {
+					files = append(files, f)
+				}
+			}
+			if len(files) > 0 {
+				out[name] = map[string]any{propType: files}
+			}
+		}
+	}
+	return out
+}
+
+// propertyFromString は設定の文字列をプロパティの型に合わせた値にする。空文字は値を消す指定として扱う。
+func propertyFromString(prop map[string]any, value string) (map[string]any, error) {
+	propType, _ := prop["type"].(string)
+	switch propType {
+	case "select", "status":
+		if value == "" {
+			return map[string]any{propType: nil}, nil
+		}
+		return map[string]any{propType: map[string]any{"name": value}}, nil
+	case "multi_select":
+		names := []map[string]any{}
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, map[string]any{"name": name})
+			}
+		}
+		return map[string]any{propType: names}, nil
+	case "rich_text":
+		return map[string]any{propType: plainRichText(value)}, nil
+	case "checkbox":
+		checked, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checkbox value: %s", value)
+		}
+		return map[string]any{propType: checked}, nil
+	case "number":
+		if value == "" {
+			return map[string]any{propType: nil}, nil
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number: %s", value)
+		}
+		return map[string]any{propType: n}, nil
+	case "url", "email", "phone_number":
+		if value == "" {
+			return map[string]any{propType: nil}, nil
+		}
+		return map[string]any{propType: value}, nil
+	case "date":
+		if value == "" {
+			return map[string]any{propType: nil}, nil
+		}
+		return map[string]any{propType: map[string]any{"start": value}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported property type: %s", propType)
+	}
+}
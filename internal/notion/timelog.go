@@ -0,0 +1,54 @@
+package notion
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// AddToNumber は number 型プロパティの現在値に delta を加算する。
+func (c *Client) AddToNumber(ctx context.Context, pageID, propertyName string, delta float64, notionVersion string) error {
+	if strings.TrimSpace(pageID) == "" {
+		return fmt.Errorf("page_id is required")
+	}
+	if strings.TrimSpace(propertyName) == "" {
+		return fmt.Errorf("property name is required")
+	}
+	var p page
+	path := fmt.Sprintf("/v1/pages/%s", pageID)
+	if err := c.doJSON(ctx, http.MethodGet, path, nil, &p, notionVersion); err != nil {
+		return err
+	}
+	prop, ok := p.Properties[propertyName]
+	if !ok {
+		return fmt.Errorf("property %q not found", propertyName)
+	}
+	if prop.Type != "number" {
+		return fmt.Errorf("property %q is not a number", propertyName)
+	}
+	current := 0.0
+	if prop.Number != nil {
+		current = *prop.Number
+	}
+	return c.SetNumber(ctx, pageID, propertyName, current+delta, notionVersion)
+}
+
+// SetNumber は number 型プロパティを value で上書きする。
+func (c *Client) SetNumber(ctx context.Context, pageID, propertyName string, value float64, notionVersion string) error {
+	payload := map[string]any{
+		"properties": map[string]any{
+			propertyName: map[string]any{"number": value},
+		},
+	}
+	path := fmt.Sprintf("/v1/pages/%s", pageID)
+	return c.doJSON(ctx, http.MethodPatch, path, payload, nil, notionVersion)
+}
+
+// AppendParagraph はページ末尾に段落ブロックを 1 つ追加する。
+func (c *Client) AppendParagraph(ctx context.Context, pageID, content, notionVersion string) error {
+	if strings.TrimSpace(pageID) == "" {
+		return fmt.Errorf("page_id is required")
+	}
+	return c.AppendBlocks(ctx, pageID, []Block{Paragraph(content)}, notionVersion)
+}
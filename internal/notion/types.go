@@ -14,16 +14,30 @@ type page struct {
 	ID             string                   `json:"id"`
 	URL            string                   `json:"url"`
 	LastEditedTime string                   `json:"last_edited_time"`
+	LastEditedBy   *user                    `json:"last_edited_by"`
 	Properties     map[string]propertyValue `json:"properties"`
 	Icon           *pageIcon                `json:"icon"`
+	Cover          *pageIcon                `json:"cover"`
+}
+
+type user struct {
+	ID string `json:"id"`
 }
 
 type propertyValue struct {
-	Type     string `json:"type"`
-	Title    []text `json:"title"`
-	Status   *name  `json:"status"`
-	Select   *name  `json:"select"`
-	Checkbox *bool  `json:"checkbox"`
+	Type     string   `json:"type"`
+	Title    []text   `json:"title"`
+	Status   *name    `json:"status"`
+	Select   *name    `json:"select"`
+	Checkbox *bool    `json:"checkbox"`
+	Date     *date    `json:"date"`
+	Number   *float64 `json:"number"`
+	People   []user   `json:"people"`
+}
+
+type date struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
 }
 
 type text struct {
@@ -79,7 +93,7 @@ type blockText struct {
 	RichText []text `json:"rich_text"`
 }
 
-func mapTasks(pages []page, titlePropertyName, statusPropertyName, checkboxPropertyName string) []dto.Task {
+func mapTasks(pages []page, titlePropertyName, statusPropertyName, checkboxPropertyName, duePropertyName, assigneePropertyName string) []dto.Task {
 	out := make([]dto.Task, 0, len(pages))
 	for _, p := range pages {
 		title := extractTitle(p.Properties[titlePropertyName])
@@ -88,13 +102,20 @@ func mapTasks(pages []page, titlePropertyName, statusPropertyName, checkboxPrope
 		if checkboxPropertyName != "" {
 			checked = extractCheckbox(p.Properties[checkboxPropertyName])
 		}
+		editedBy := ""
+		if p.LastEditedBy != nil {
+			editedBy = p.LastEditedBy.ID
+		}
 		out = append(out, dto.Task{
 			ID:             p.ID,
 			Title:          title,
 			URL:            p.URL,
 			Status:         status,
 			LastEditedTime: p.LastEditedTime,
+			LastEditedBy:   editedBy,
 			Checked:        checked,
+			Due:            extractDate(p.Properties[duePropertyName]),
+			Assignees:      extractPeople(p.Properties[assigneePropertyName]),
 		})
 	}
 	return out
@@ -127,6 +148,24 @@ func extractCheckbox(prop propertyValue) bool {
 	return *prop.Checkbox
 }
 
+func extractDate(prop propertyValue) string {
+	if prop.Type != "date" || prop.Date == nil {
+		return ""
+	}
+	return prop.Date.Start
+}
+
+func extractPeople(prop propertyValue) []string {
+	if prop.Type != "people" || len(prop.People) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(prop.People))
+	for _, person := range prop.People {
+		ids = append(ids, person.ID)
+	}
+	return ids
+}
+
 func extractTitleFromProperties(props map[string]propertyValue) string {
 	for _, prop := range props {
 		if prop.Type == "title" {
@@ -145,16 +184,6 @@ func extractTitleProperty(props map[string]propertyValue) (string, string) {
 	return "", ""
 }
 
-func blocksToPlainText(blocks []block) string {
-	lines := make([]string, 0, len(blocks))
-	for _, b := range blocks {
-		if line := blockToLine(b); line != "" {
-			lines = append(lines, line)
-		}
-	}
-	return strings.Join(lines, "\n")
-}
-
 func blockToLine(b block) string {
 	text := extractBlockText(b)
 	if text == "" {
@@ -0,0 +1,64 @@
+package notion
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"nudge/internal/dto"
+	"nudge/internal/store"
+)
+
+// identityNotionVersion は設定に Notion-Version がまだないとき、トークンの確認に使う版。
+// /v1/users/me の応答は版によって変わらない。
+const identityNotionVersion = "2022-06-28"
+
+type botUserResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Bot  *struct {
+		WorkspaceName string `json:"workspace_name"`
+	} `json:"bot"`
+}
+
+// Me はトークンストアのトークンが指すインテグレーションを返す。
+func (c *Client) Me(ctx context.Context, notionVersion string) (dto.TokenIdentity, error) {
+	token, err := c.token()
+	if err != nil {
+		return dto.TokenIdentity{}, err
+	}
+	identity, err := c.VerifyToken(ctx, token, notionVersion)
+	if errors.Is(err, ErrUnauthorized) {
+		// 拒否されたトークンをキャッシュに残さず、次の確認では保存先から読み直す
+		if invalidator, ok := c.tokenStore.(store.TokenInvalidator); ok {
+			invalidator.InvalidateToken()
+		}
+	}
+	return identity, err
+}
+
+// VerifyToken は保存前のトークンで GET /v1/users/me を呼び、インテグレーション名とワークスペースを返す。
+// トークンが拒否された場合は ErrUnauthorized を返す。
+func (c *Client) VerifyToken(ctx context.Context, token, notionVersion string) (dto.TokenIdentity, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return dto.TokenIdentity{}, ErrTokenNotSet
+	}
+	if notionVersion == "" {
+		notionVersion = c.version
+	}
+	if notionVersion == "" {
+		notionVersion = identityNotionVersion
+	}
+	var resp botUserResponse
+	if err := c.doJSONWithToken(ctx, token, http.MethodGet, "/v1/users/me", nil, &resp, notionVersion); err != nil {
+		return dto.TokenIdentity{}, err
+	}
+	identity := dto.TokenIdentity{ID: resp.ID, Name: resp.Name}
+	if resp.Bot != nil {
+		identity.WorkspaceName = resp.Bot.WorkspaceName
+	}
+	return identity, nil
+}
@@ -0,0 +1,231 @@
+package oauth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	callbackPath    = "/callback"
+	exchangeTimeout = 30 * time.Second
+	shutdownTimeout = 2 * time.Second
+)
+
+var (
+	// ErrDenied はユーザーがブラウザで接続を許可しなかったことを表す。
+	ErrDenied = errors.New("authorization was denied")
+	// ErrStateMismatch はリダイレクトの state が送ったものと一致しないことを表す。
+	ErrStateMismatch = errors.New("authorization state mismatch")
+)
+
+// Config は認可コードフローの設定。エンドポイントは Notion の代わりに手元の認可サーバを指してもよい。
+type Config struct {
+	ClientID string
+	// ClientSecret は任意。トークンエンドポイントが秘密情報を付けて中継するサーバなら空でよい
+	ClientSecret string
+	AuthorizeURL string
+	TokenURL     string
+	// RedirectPort はリダイレクトを待ち受けるループバックのポート。0 なら空いているポートを使う
+	RedirectPort int
+}
+
+// Token はトークンエンドポイントの応答。Notion はアクセストークンと接続先のワークスペースを返す。
+type Token struct {
+	AccessToken   string `json:"access_token"`
+	TokenType     string `json:"token_type"`
+	RefreshToken  string `json:"refresh_token,omitempty"`
+	BotID         string `json:"bot_id"`
+	WorkspaceID   string `json:"workspace_id"`
+	WorkspaceName string `json:"workspace_name"`
+	WorkspaceIcon string `json:"workspace_icon"`
+}
+
+// Flow は 1 回分の認可コードフロー。ブラウザで認可画面を開き、ループバックで受けたコードをトークンに交換する。
+type Flow struct {
+	Config Config
+	// OpenBrowser は認可画面の URL を開く。
+	OpenBrowser func(url string) error
+	// HTTPClient はトークンの交換に使う。nil なら http.DefaultClient
+	HTTPClient *http.Client
+}
+
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// Run は認可からトークンの交換までを行う。ブラウザでの操作を待つため、ctx で打ち切る。
+func (f *Flow) Run(ctx context.Context) (Token, error) {
+	if strings.TrimSpace(f.Config.ClientID) == "" {
+		return Token{}, fmt.Errorf("oauth client_id is empty")
+	}
+	if f.OpenBrowser == nil {
+		return Token{}, fmt.Errorf("oauth browser opener is not set")
+	}
+	state, err := randomState()
+	if err != nil {
+		return Token{}, err
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", f.Config.RedirectPort))
+	if err != nil {
+		return Token{}, fmt.Errorf("listen for oauth redirect: %w", err)
+	}
+	redirectURI := fmt.Sprintf("http://127.0.0.1:%d%s", listener.Addr().(*net.TCPAddr).Port, callbackPath)
+
+	results := make(chan callbackResult, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		result := parseCallback(r.URL.Query(), state)
+		if result.err != nil {
+			writePage(w, http.StatusBadRequest, "Notion と接続できませんでした", result.err.Error())
+		} else {
+			writePage(w, http.StatusOK, "Notion と接続しました", "このタブを閉じて Nudge に戻ってください。")
+		}
+		if errors.Is(result.err, ErrStateMismatch) {
+			// 別のページからのアクセスではフローを終わらせず、本物のリダイレクトを待ち続ける
+			return
+		}
+		select {
+		case results <- result:
+		default:
+		}
+	})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	authURL, err := f.authorizeURL(redirectURI, state)
+	if err != nil {
+		return Token{}, err
+	}
+	if err := f.OpenBrowser(authURL); err != nil {
+		return Token{}, fmt.Errorf("open browser: %w", err)
+	}
+
+	var result callbackResult
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return Token{}, fmt.Errorf("wait for oauth redirect: %w", ctx.Err())
+	}
+	if result.err != nil {
+		return Token{}, result.err
+	}
+	return f.exchange(ctx, result.code, redirectURI)
+}
+
+func (f *Flow) authorizeURL(redirectURI, state string) (string, error) {
+	u, err := url.Parse(f.Config.AuthorizeURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid oauth authorize_url: %q", f.Config.AuthorizeURL)
+	}
+	q := u.Query()
+	q.Set("client_id", f.Config.ClientID)
+	q.Set("response_type", "code")
+	q.Set("owner", "user")
+	q.Set("redirect_uri", redirectURI)
+	q.Set("state", state)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// exchange は認可コードをトークンエンドポイントでアクセストークンに交換する。
+func (f *Flow) exchange(ctx context.Context, code, redirectURI string) (Token, error) {
+	body := map[string]string{
+		"grant_type":   "authorization_code",
+		"code":         code,
+		"redirect_uri": redirectURI,
+	}
+	if f.Config.ClientSecret == "" {
+		// 中継サーバにどのクライアントの交換かを伝える（秘密情報は中継サーバ側で付ける）
+		body["client_id"] = f.Config.ClientID
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return Token{}, fmt.Errorf("marshal token request: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, exchangeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.Config.TokenURL, bytes.NewReader(payload))
+	if err != nil {
+		return Token{}, fmt.Errorf("new token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if f.Config.ClientSecret != "" {
+		req.SetBasicAuth(f.Config.ClientID, f.Config.ClientSecret)
+	}
+	client := f.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Token{}, fmt.Errorf("token request: %w", err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Token{}, fmt.Errorf("token endpoint error: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	var token Token
+	if err := json.Unmarshal(b, &token); err != nil {
+		return Token{}, fmt.Errorf("decode token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return Token{}, fmt.Errorf("token response has no access_token")
+	}
+	return token, nil
+}
+
+// parseCallback はリダイレクトのクエリから認可コードを取り出す。state が一致しなければ拒否する。
+func parseCallback(q url.Values, state string) callbackResult {
+	if subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(state)) != 1 {
+		return callbackResult{err: ErrStateMismatch}
+	}
+	if e := q.Get("error"); e != "" {
+		if e == "access_denied" {
+			return callbackResult{err: ErrDenied}
+		}
+		return callbackResult{err: fmt.Errorf("authorization failed: %s", e)}
+	}
+	code := q.Get("code")
+	if code == "" {
+		return callbackResult{err: fmt.Errorf("authorization response has no code")}
+	}
+	return callbackResult{code: code}
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate oauth state: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func writePage(w http.ResponseWriter, status int, title, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<!doctype html><meta charset=\"utf-8\"><title>%[1]s</title><h1>%[1]s</h1><p>%[2]s</p>",
+		html.EscapeString(title), html.EscapeString(message))
+}
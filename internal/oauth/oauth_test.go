@@ -0,0 +1,217 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tokenServer はトークンエンドポイントの代わり。受け取った要求を check に渡し、成功ならトークンを返す。
+func tokenServer(t *testing.T, check func(r *http.Request, body map[string]string) error) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := check(r, body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"secret-token","token_type":"bearer","bot_id":"bot-1","workspace_id":"ws-1","workspace_name":"Work","workspace_icon":"🗂"}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// redirect はブラウザの代わりにリダイレクト先へアクセスし、ステータスコードを返す。
+func redirect(redirectURI string, query url.Values) (int, error) {
+	resp, err := http.Get(redirectURI + "?" + query.Encode())
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// authorizeParams は認可 URL からリダイレクト先と state を取り出す。
+func authorizeParams(t *testing.T, authURL string) (redirectURI, state string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse authorize url: %v", err)
+	}
+	q := u.Query()
+	if q.Get("client_id") != "client-1" || q.Get("response_type") != "code" || q.Get("owner") != "user" {
+		t.Errorf("authorize query = %v", q)
+	}
+	return q.Get("redirect_uri"), q.Get("state")
+}
+
+func testContext(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestFlowExchangesCode(t *testing.T) {
+	var redirectURI string
+	srv, calls := tokenServer(t, func(r *http.Request, body map[string]string) error {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "client-1" || pass != "client-secret" {
+			return fmt.Errorf("basic auth = %q %q %v", user, pass, ok)
+		}
+		if body["grant_type"] != "authorization_code" || body["code"] != "code-1" || body["redirect_uri"] != redirectURI {
+			return fmt.Errorf("body = %v", body)
+		}
+		if _, ok := body["client_id"]; ok {
+			return fmt.Errorf("client_id is sent with a client secret")
+		}
+		return nil
+	})
+	flow := &Flow{
+		Config: Config{
+			ClientID:     "client-1",
+			ClientSecret: "client-secret",
+			AuthorizeURL: "https://auth.example.com/authorize",
+			TokenURL:     srv.URL,
+		},
+		OpenBrowser: func(authURL string) error {
+			var state string
+			redirectURI, state = authorizeParams(t, authURL)
+			// 別のページからの state の違うアクセスは拒否し、フローは待ち続ける
+			status, err := redirect(redirectURI, url.Values{"code": {"forged"}, "state": {"other"}})
+			if err != nil {
+				return err
+			}
+			if status != http.StatusBadRequest {
+				t.Errorf("state mismatch status = %d, want %d", status, http.StatusBadRequest)
+			}
+			status, err = redirect(redirectURI, url.Values{"code": {"code-1"}, "state": {state}})
+			if err != nil {
+				return err
+			}
+			if status != http.StatusOK {
+				t.Errorf("callback status = %d, want %d", status, http.StatusOK)
+			}
+			return nil
+		},
+	}
+
+	token, err := flow.Run(testContext(t))
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if token.AccessToken != "secret-token" || token.WorkspaceID != "ws-1" || token.WorkspaceName != "Work" || token.BotID != "bot-1" {
+		t.Errorf("token = %+v", token)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("token endpoint calls = %d, want 1", got)
+	}
+}
+
+func TestFlowSendsClientIDWithoutSecret(t *testing.T) {
+	srv, _ := tokenServer(t, func(r *http.Request, body map[string]string) error {
+		if _, _, ok := r.BasicAuth(); ok {
+			return fmt.Errorf("basic auth is sent without a client secret")
+		}
+		if body["client_id"] != "client-1" {
+			return fmt.Errorf("client_id = %q", body["client_id"])
+		}
+		return nil
+	})
+	flow := &Flow{
+		Config: Config{ClientID: "client-1", AuthorizeURL: "https://auth.example.com/authorize", TokenURL: srv.URL},
+		OpenBrowser: func(authURL string) error {
+			redirectURI, state := authorizeParams(t, authURL)
+			_, err := redirect(redirectURI, url.Values{"code": {"code-1"}, "state": {state}})
+			return err
+		},
+	}
+	if _, err := flow.Run(testContext(t)); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func TestFlowDenied(t *testing.T) {
+	srv, calls := tokenServer(t, func(*http.Request, map[string]string) error { return nil })
+	flow := &Flow{
+		Config: Config{ClientID: "client-1", AuthorizeURL: "https://auth.example.com/authorize", TokenURL: srv.URL},
+		OpenBrowser: func(authURL string) error {
+			redirectURI, state := authorizeParams(t, authURL)
+			status, err := redirect(redirectURI, url.Values{"error": {"access_denied"}, "state": {state}})
+			if err == nil && status != http.StatusBadRequest {
+				t.Errorf("denied status = %d, want %d", status, http.StatusBadRequest)
+			}
+			return err
+		},
+	}
+	_, err := flow.Run(testContext(t))
+	if !errors.Is(err, ErrDenied) {
+		t.Fatalf("Run error = %v, want ErrDenied", err)
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("token endpoint calls = %d, want 0", got)
+	}
+}
+
+func TestFlowWaitsPastStateMismatch(t *testing.T) {
+	srv, calls := tokenServer(t, func(*http.Request, map[string]string) error { return nil })
+	flow := &Flow{
+		Config: Config{ClientID: "client-1", AuthorizeURL: "https://auth.example.com/authorize", TokenURL: srv.URL},
+		OpenBrowser: func(authURL string) error {
+			redirectURI, _ := authorizeParams(t, authURL)
+			_, err := redirect(redirectURI, url.Values{"code": {"forged"}, "state": {"other"}})
+			return err
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := flow.Run(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run error = %v, want to keep waiting until the deadline", err)
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("token endpoint calls = %d, want 0", got)
+	}
+}
+
+func TestParseCallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   url.Values
+		code    string
+		wantErr error
+	}{
+		{name: "code", query: url.Values{"state": {"s"}, "code": {"c"}}, code: "c"},
+		{name: "state mismatch", query: url.Values{"state": {"x"}, "code": {"c"}}, wantErr: ErrStateMismatch},
+		{name: "missing state", query: url.Values{"code": {"c"}}, wantErr: ErrStateMismatch},
+		{name: "denied", query: url.Values{"state": {"s"}, "error": {"access_denied"}}, wantErr: ErrDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseCallback(tt.query, "s")
+			if tt.wantErr != nil {
+				if !errors.Is(got.err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", got.err, tt.wantErr)
+				}
+				return
+			}
+			if got.err != nil || got.code != tt.code {
+				t.Fatalf("got %+v, want code %q", got, tt.code)
+			}
+		})
+	}
+}
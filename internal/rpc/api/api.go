@@ -0,0 +1,169 @@
+// Package api はフロントエンドに公開する RPC アクションの一覧と要求/応答の型を定義する。
+// 変更後は `go generate ./cmd/nudge` で JS クライアントと JSON Schema を再生成する。
+package api
+
+import (
+	"nudge/internal/dto"
+	"nudge/internal/rpc"
+)
+
+type SetTokenRequest struct {
+	Token string `json:"token"`
+	// Account はトークンのアカウント ID。空なら有効なプロファイルのトークン
+	Account string `json:"account,omitempty"`
+}
+
+type TokenAccountRequest struct {
+	Account string `json:"account,omitempty"`
+}
+
+type OAuthClientSecretRequest struct {
+	Secret string `json:"secret"`
+}
+
+type ResolveRequest struct {
+	DatabaseID string `json:"database_id"`
+	Account    string `json:"account,omitempty"`
+}
+
+type BrainTemplateRequest struct {
+	Profile string `json:"profile"` // Brain プロファイルのキー（空なら先頭）
+}
+
+type CreateBrainPageRequest struct {
+	Profile string `json:"profile"`
+	Body    string `json:"body"`
+}
+
+type BrainDraftRequest struct {
+	Profile string `json:"profile"`
+}
+
+type SaveBrainDraftRequest struct {
+	Profile string `json:"profile"`
+	Body    string `json:"body"`
+}
+
+type GetTasksRequest struct {
+	DatabaseKey  string `json:"database_key"`
+	ForceRefresh bool   `json:"force_refresh"`
+}
+
+type GetHabitsRequest struct {
+	DatabaseKey  string `json:"database_key"`
+	ForceRefresh bool   `json:"force_refresh"`
+}
+
+type UpdateStatusRequest struct {
+	DatabaseKey string `json:"database_key"`
+	TaskID      string `json:"task_id"`
+	Action      string `json:"action"` // done | paused | resume
+}
+
+type UpdateHabitCheckRequest struct {
+	DatabaseKey string `json:"database_key"`
+	TaskID      string `json:"task_id"`
+	Checked     bool   `json:"checked"`
+}
+
+type StartFocusRequest struct {
+	DatabaseKey string `json:"database_key"`
+	TaskID      string `json:"task_id"`
+}
+
+type TimeSummaryRequest struct {
+	Period string `json:"period"` // day | week
+	Date   string `json:"date"`   // YYYY-MM-DD（空なら今日）
+}
+
+type ExportTimeCSVRequest struct {
+	From string `json:"from"` // YYYY-MM-DD
+	To   string `json:"to"`   // YYYY-MM-DD（この日を含む）
+}
+
+type ProfileRequest struct {
+	Name string `json:"name"`
+}
+
+type OpenURLRequest struct {
+	URL string `json:"url"`
+}
+
+var (
+	GetConfig                = rpc.Endpoint[rpc.Empty, dto.Config]{Name: "getConfig", Doc: "設定ファイルを読み込み直して返す", CancelOnHide: true}
+	SaveConfig               = rpc.Endpoint[dto.Config, string]{Name: "saveConfig", Doc: "設定を検証して保存しポーリングを再開し、保存後の revision を返す。読み込み後にファイルが変わっていれば config_conflict"}
+	ValidateConfig           = rpc.Endpoint[dto.Config, []dto.FieldError]{Name: "validateConfig", Doc: "設定を保存せずに検証し、誤りのある項目を返す", CancelOnHide: true}
+	GetTokenStatus           = rpc.Endpoint[TokenAccountRequest, bool]{Name: "getTokenStatus", Doc: "アカウントのトークンが保存済みかを返す", CancelOnHide: true}
+	SetToken                 = rpc.Endpoint[SetTokenRequest, dto.TokenIdentity]{Name: "setToken", Doc: "トークンを Notion に確認してアカウントに保存し、インテグレーションの情報を返す"}
+	VerifyToken              = rpc.Endpoint[TokenAccountRequest, dto.TokenIdentity]{Name: "verifyToken", Doc: "保存済みのトークンを Notion に確認し、インテグレーション名とワークスペースを返す", CancelOnHide: true}
+	ClearToken               = rpc.Endpoint[TokenAccountRequest, rpc.Empty]{Name: "clearToken", Doc: "アカウントのトークンを削除する"}
+	ConnectNotion            = rpc.Endpoint[TokenAccountRequest, dto.NotionWorkspace]{Name: "connectNotion", Doc: "OAuth でブラウザから Notion と接続し、アクセストークンをアカウントに保存する"}
+	SetOAuthClientSecret     = rpc.Endpoint[OAuthClientSecretRequest, rpc.Empty]{Name: "setOAuthClientSecret", Doc: "OAuth のクライアントシークレットを保存する（空なら削除）"}
+	ResolveDataSourceID      = rpc.Endpoint[ResolveRequest, string]{Name: "resolveDataSourceID", Doc: "Database ID から Data Source ID を解決する", CancelOnHide: true}
+	ResolveTitlePropertyName = rpc.Endpoint[ResolveRequest, string]{Name: "resolveTitlePropertyName", Doc: "Database のタイトルプロパティ名を解決する", CancelOnHide: true}
+	GetBrainTemplate         = rpc.Endpoint[BrainTemplateRequest, dto.BrainTemplate]{Name: "getBrainTemplate", Doc: "Brain プロファイルのテンプレートを取得する", CancelOnHide: true}
+	CreateBrainPage          = rpc.Endpoint[CreateBrainPageRequest, dto.CreatedPage]{Name: "createBrainPage", Doc: "Brain プロファイルの DB にページを作成する"}
+	GetBrainDraft            = rpc.Endpoint[BrainDraftRequest, dto.BrainDraft]{Name: "getBrainDraft", Doc: "Brain プロファイルの未登録の下書きを返す", CancelOnHide: true}
+	SaveBrainDraft           = rpc.Endpoint[SaveBrainDraftRequest, rpc.Empty]{Name: "saveBrainDraft", Doc: "Brain の下書きを更新する（ファイルへの書き込みは少し遅れる）"}
+	GetBrainNotes            = rpc.Endpoint[rpc.Empty, []dto.BrainNote]{Name: "getBrainNotes", Doc: "Brain から登録したメモの履歴を新しい順に返す", CancelOnHide: true}
+	CreateDailyReview        = rpc.Endpoint[rpc.Empty, dto.CreatedPage]{Name: "createDailyReview", Doc: "今日のまとめを Brain にページとして作成する"}
+	GetTasks                 = rpc.Endpoint[GetTasksRequest, []dto.Task]{Name: "getTasks", Doc: "進行中タスクを返す（キャッシュ優先）", CancelOnHide: true}
+	GetHabits                = rpc.Endpoint[GetHabitsRequest, []dto.Task]{Name: "getHabits", Doc: "今日の未チェック習慣を返す（キャッシュ優先）", CancelOnHide: true}
+	UpdateStatus             = rpc.Endpoint[UpdateStatusRequest, rpc.Empty]{Name: "updateStatus", Doc: "タスクのステータスを更新する"}
+	UpdateHabitCheck         = rpc.Endpoint[UpdateHabitCheckRequest, rpc.Empty]{Name: "updateHabitCheck", Doc: "今日の習慣チェックを更新する"}
+	GetFocus                 = rpc.Endpoint[rpc.Empty, *dto.FocusSession]{Name: "getFocus", Doc: "実行中の集中セッションを返す（なければ null）", CancelOnHide: true}
+	StartFocus               = rpc.Endpoint[StartFocusRequest, dto.FocusSession]{Name: "startFocus", Doc: "タスクの集中セッションを開始する"}
+	PauseFocus               = rpc.Endpoint[rpc.Empty, dto.FocusSession]{Name: "pauseFocus", Doc: "集中セッションを一時停止する"}
+	ResumeFocus              = rpc.Endpoint[rpc.Empty, dto.FocusSession]{Name: "resumeFocus", Doc: "集中セッションを再開する"}
+	FinishFocus              = rpc.Endpoint[rpc.Empty, rpc.Empty]{Name: "finishFocus", Doc: "集中セッションを終了し経過時間を記録する"}
+	CancelFocus              = rpc.Endpoint[rpc.Empty, rpc.Empty]{Name: "cancelFocus", Doc: "集中セッションを記録せずに破棄する"}
+	GetTimeSummary           = rpc.Endpoint[TimeSummaryRequest, dto.TimeSummary]{Name: "getTimeSummary", Doc: "日/週の作業時間を DB・タスクごとに集計する", CancelOnHide: true}
+	ExportTimeCSV            = rpc.Endpoint[ExportTimeCSVRequest, string]{Name: "exportTimeCSV", Doc: "期間の作業時間を CSV に書き出し、保存先のパスを返す"}
+	SyncTimeTotals           = rpc.Endpoint[rpc.Empty, int]{Name: "syncTimeTotals", Doc: "累計作業時間を Notion の数値プロパティへ書き込み、更新件数を返す"}
+	SwitchProfile            = rpc.Endpoint[ProfileRequest, rpc.Empty]{Name: "switchProfile", Doc: "設定プロファイルを切り替えてポーリングをやり直す"}
+	ExportProfile            = rpc.Endpoint[ProfileRequest, string]{Name: "exportProfile", Doc: "プロファイルをトークンを含まない JSON に書き出し、保存先のパスを返す"}
+	ImportProfile            = rpc.Endpoint[dto.ProfileBundle, string]{Name: "importProfile", Doc: "書き出したプロファイルを取り込み、プロファイル名を返す"}
+	OpenURL                  = rpc.Endpoint[OpenURLRequest, rpc.Empty]{Name: "openURL", Doc: "既定のブラウザで URL を開く"}
+	OpenSettingsWindow       = rpc.Endpoint[rpc.Empty, rpc.Empty]{Name: "openSettingsWindow", Doc: "設定ウィンドウを表示する"}
+	OpenBrainWindow          = rpc.Endpoint[rpc.Empty, rpc.Empty]{Name: "openBrainWindow", Doc: "Brain ウィンドウを表示する"}
+)
+
+// Actions はコード生成の対象となるアクション一覧（生成物の並び順もこの順）。
+var Actions = []rpc.Describer{
+	GetConfig,
+	SaveConfig,
+	ValidateConfig,
+	GetTokenStatus,
+	SetToken,
+	VerifyToken,
+	ConnectNotion,
+	SetOAuthClientSecret,
+	ClearToken,
+	ResolveDataSourceID,
+	ResolveTitlePropertyName,
+	GetBrainTemplate,
+	CreateBrainPage,
+	GetBrainDraft,
+	SaveBrainDraft,
+	GetBrainNotes,
+	CreateDailyReview,
+	GetTasks,
+	GetHabits,
+	UpdateStatus,
+	UpdateHabitCheck,
+	GetFocus,
+	StartFocus,
+	PauseFocus,
+	ResumeFocus,
+	FinishFocus,
+	CancelFocus,
+	GetTimeSummary,
+	ExportTimeCSV,
+	SyncTimeTotals,
+	SwitchProfile,
+	ExportProfile,
+	ImportProfile,
+	OpenURL,
+	OpenSettingsWindow,
+	OpenBrainWindow,
+}
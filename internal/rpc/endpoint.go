@@ -0,0 +1,35 @@
+package rpc
+
+import "reflect"
+
+// Endpoint はアクション名と要求/応答の型を束ねた定義。
+// ハンドラ登録とクライアント生成の両方がこの定義を参照するため、型のずれはコンパイル時に検出できる。
+type Endpoint[Req, Resp any] struct {
+	Name string
+	Doc  string
+	// CancelOnHide は呼び出し元のウィンドウが隠れたら打ち切ってよいアクション（途中でやめても副作用のない読み取り）。
+	// 書き込みを伴うアクションは隠れても最後まで実行する。
+	CancelOnHide bool
+}
+
+// Spec はコード生成用にリフレクションで取り出したアクション定義。
+type Spec struct {
+	Name     string
+	Doc      string
+	Request  reflect.Type
+	Response reflect.Type
+}
+
+// Describer は Spec を返す Endpoint の共通インターフェース。
+type Describer interface {
+	Spec() Spec
+}
+
+func (e Endpoint[Req, Resp]) Spec() Spec {
+	return Spec{
+		Name:     e.Name,
+		Doc:      e.Doc,
+		Request:  reflect.TypeFor[Req](),
+		Response: reflect.TypeFor[Resp](),
+	}
+}
@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// エラーコード。フロントエンドは code で分岐し、error は表示用に使う。
+const (
+	CodeInvalidRequest = "invalid_request"
+	CodeInvalidPayload = "invalid_payload"
+	CodeUnknownAction  = "unknown_action"
+	CodeForbidden      = "forbidden"
+	CodeTimeout        = "timeout"
+	CodeCanceled       = "canceled"
+	CodeInternal       = "internal"
+	CodeFailed         = "failed"
+)
+
+// Error はコード付きのエラー。
+type Error struct {
+	Code string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Code
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Errorf はコード付きのエラーを作る。
+func Errorf(code, format string, args ...any) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// WithCode は既存のエラーにコードを付ける。
+func WithCode(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// CodeOf はエラーに対応するコードを返す。コードが無い場合は CodeFailed。
+func CodeOf(err error) string {
+	if err == nil {
+		return ""
+	}
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) && rpcErr.Code != "" {
+		return rpcErr.Code
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	case errors.Is(err, context.Canceled):
+		return CodeCanceled
+	default:
+		return CodeFailed
+	}
+}
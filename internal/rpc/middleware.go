@@ -0,0 +1,112 @@
+package rpc
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// TrustedOrigin は埋め込み webview のメインフレーム以外からの呼び出しを拒否する。
+func TrustedOrigin(prefixes ...string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (any, error) {
+			if !isTrusted(call.Origin, prefixes) {
+				return nil, Errorf(CodeForbidden, "untrusted origin")
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+func isTrusted(origin *Origin, prefixes []string) bool {
+	if origin == nil {
+		return true
+	}
+	if !origin.IsMainFrame {
+		return false
+	}
+	if origin.Origin == "" {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(origin.Origin, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Timeout は 1 呼び出しごとに期限を設ける。overrides でアクション単位に上書きできる。
+func Timeout(d time.Duration, overrides map[string]time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (any, error) {
+			timeout := d
+			if v, ok := overrides[call.Action]; ok {
+				timeout = v
+			}
+			if timeout <= 0 {
+				return next(ctx, call)
+			}
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return next(ctx, call)
+		}
+	}
+}
+
+// Recover はハンドラ内の panic を CodeInternal のエラーに変換する。
+func Recover(logger *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (data any, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					if logger != nil {
+						logger.Error("rpc panic", "action", call.Action, "panic", r, "stack", string(debug.Stack()))
+					}
+					data = nil
+					err = Errorf(CodeInternal, "internal error: %v", r)
+				}
+			}()
+			return next(ctx, call)
+		}
+	}
+}
+
+// Logging は呼び出しごとの所要時間と失敗を記録する。
+func Logging(logger *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (any, error) {
+			start := time.Now()
+			data, err := next(ctx, call)
+			if logger == nil {
+				return data, err
+			}
+			attrs := []any{"action", call.Action, "window", call.Window, "elapsed", time.Since(start)}
+			if err != nil {
+				logger.Warn("rpc failed", append(attrs, "code", CodeOf(err), "error", err)...)
+			} else {
+				logger.Debug("rpc done", attrs...)
+			}
+			return data, err
+		}
+	}
+}
+
+// ErrorCodes はアプリ固有のエラーにコードを割り当てる。
+// 既にコードを持つエラーと、mapper が空文字を返したエラーはそのまま返す。
+func ErrorCodes(mapper func(error) string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (any, error) {
+			data, err := next(ctx, call)
+			if err == nil || mapper == nil || CodeOf(err) != CodeFailed {
+				return data, err
+			}
+			if code := mapper(err); code != "" {
+				return nil, &Error{Code: code, Err: err}
+			}
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// Request はフロントエンドから届く RPC メッセージ。
+type Request struct {
+	ID      string          `json:"id"`
+	Action  string          `json:"action"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Response はフロントエンドへ返す RPC 結果。
+type Response struct {
+	ID    string `json:"id"`
+	OK    bool   `json:"ok"`
+	Data  any    `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+}
+
+// Origin は呼び出し元フレームの情報。
+type Origin struct {
+	Origin      string
+	IsMainFrame bool
+}
+
+// Call は 1 回の呼び出しの文脈。
+type Call struct {
+	Request
+	// Window は呼び出し元ウィンドウ名（非表示時のキャンセル単位）。
+	Window string
+	// Origin は不明な場合 nil。
+	Origin *Origin
+}
+
+// Handler は 1 アクションの処理。
+type Handler func(ctx context.Context, call *Call) (any, error)
+
+// Middleware は Handler を包んで共通処理を差し込む。
+type Middleware func(next Handler) Handler
+
+// Empty はペイロード/戻り値を持たないアクション用の型。
+type Empty struct{}
+
+// Router はアクション名から Handler を引き、ミドルウェアを通して実行する。
+type Router struct {
+	mu         sync.Mutex
+	handlers   map[string]Handler
+	middleware []Middleware
+	inflight   map[string]map[string]context.CancelFunc
+	// cancelable は CancelWindow で打ち切ってよいアクション（Endpoint.CancelOnHide）。
+	cancelable map[string]bool
+}
+
+func NewRouter() *Router {
+	return &Router{
+		handlers:   make(map[string]Handler),
+		inflight:   make(map[string]map[string]context.CancelFunc),
+		cancelable: make(map[string]bool),
+	}
+}
+
+// Use はミドルウェアを追加する。先に追加したものほど外側で実行される。
+func (r *Router) Use(mw ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middleware = append(r.middleware, mw...)
+}
+
+func (r *Router) Handle(action string, h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[action] = h
+}
+
+// Register は Endpoint に型付きのハンドラを登録する。ペイロードは Req にデコードされ、
+// 戻り値が Empty の場合は data を省略する。
+func Register[Req, Resp any](r *Router, ep Endpoint[Req, Resp], h func(ctx context.Context, req Req) (Resp, error)) {
+	if ep.CancelOnHide {
+		r.mu.Lock()
+		r.cancelable[ep.Name] = true
+		r.mu.Unlock()
+	}
+	r.Handle(ep.Name, func(ctx context.Context, call *Call) (any, error) {
+		var req Req
+		if len(call.Payload) > 0 && string(call.Payload) != "null" {
+			if err := json.Unmarshal(call.Payload, &req); err != nil {
+				return nil, WithCode(CodeInvalidPayload, err)
+			}
+		}
+		resp, err := h(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := any(resp).(Empty); ok {
+			return nil, nil
+		}
+		return resp, nil
+	})
+}
+
+// Dispatch は呼び出しを実行して Response を返す。
+func (r *Router) Dispatch(parent context.Context, call *Call) Response {
+	if call.ID == "" || call.Action == "" {
+		return Response{ID: call.ID, OK: false, Error: "id and action are required", Code: CodeInvalidRequest}
+	}
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	if r.track(call, cancel) {
+		defer r.untrack(call)
+	}
+
+	data, err := r.chain(call.Action)(ctx, call)
+	if err != nil {
+		return Response{ID: call.ID, OK: false, Error: err.Error(), Code: CodeOf(err)}
+	}
+	return Response{ID: call.ID, OK: true, Data: data}
+}
+
+// CancelWindow はウィンドウから発行され処理中の呼び出しのうち、打ち切ってよいもの（Endpoint.CancelOnHide）をキャンセルする。
+func (r *Router) CancelWindow(window string) {
+	r.mu.Lock()
+	calls := r.inflight[window]
+	delete(r.inflight, window)
+	r.mu.Unlock()
+	for _, cancel := range calls {
+		cancel()
+	}
+}
+
+func (r *Router) chain(action string) Handler {
+	r.mu.Lock()
+	h, ok := r.handlers[action]
+	middleware := r.middleware
+	r.mu.Unlock()
+	if !ok {
+		h = func(ctx context.Context, call *Call) (any, error) {
+			return nil, Errorf(CodeUnknownAction, "unknown action")
+		}
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// track は打ち切ってよいアクションの呼び出しを CancelWindow の対象として記録し、記録したかを返す。
+func (r *Router) track(call *Call, cancel context.CancelFunc) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.cancelable[call.Action] {
+		return false
+	}
+	calls, ok := r.inflight[call.Window]
+	if !ok {
+		calls = make(map[string]context.CancelFunc)
+		r.inflight[call.Window] = calls
+	}
+	calls[call.ID] = cancel
+	return true
+}
+
+func (r *Router) untrack(call *Call) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls, ok := r.inflight[call.Window]
+	if !ok {
+		return
+	}
+	delete(calls, call.ID)
+	if len(calls) == 0 {
+		delete(r.inflight, call.Window)
+	}
+}
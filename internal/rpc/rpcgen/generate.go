@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"nudge/internal/rpc"
+)
+
+const header = "// Code generated by rpcgen from internal/rpc/api; DO NOT EDIT.\n"
+
+var emptyType = reflect.TypeFor[rpc.Empty]()
+
+type generator struct {
+	actions []rpc.Spec
+	// defs は名前付き構造体の定義（JSON Schema の $defs / JSDoc の typedef）。
+	defs  map[string]reflect.Type
+	names map[reflect.Type]string
+}
+
+func newGenerator() *generator {
+	return &generator{
+		defs:  make(map[string]reflect.Type),
+		names: make(map[reflect.Type]string),
+	}
+}
+
+func (g *generator) addAction(spec rpc.Spec) {
+	g.actions = append(g.actions, spec)
+	g.collect(spec.Request)
+	g.collect(spec.Response)
+}
+
+// collect は型に含まれる名前付き構造体を再帰的に登録する。
+func (g *generator) collect(t reflect.Type) {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		g.collect(t.Elem())
+	case reflect.Struct:
+		if t == emptyType || t.Name() == "" {
+			for _, f := range jsonFields(t) {
+				g.collect(f.Type)
+			}
+			return
+		}
+		if _, ok := g.names[t]; ok {
+			return
+		}
+		name := t.Name()
+		if other, ok := g.defs[name]; ok && other != t {
+			name = exportedPackageName(t) + name
+		}
+		g.defs[name] = t
+		g.names[t] = name
+		for _, f := range jsonFields(t) {
+			g.collect(f.Type)
+		}
+	}
+}
+
+type jsonField struct {
+	Name     string
+	Type     reflect.Type
+	Optional bool
+}
+
+func jsonFields(t reflect.Type) []jsonField {
+	var out []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		out = append(out, jsonField{
+			Name:     name,
+			Type:     f.Type,
+			Optional: strings.Contains(opts, "omitempty") || f.Type.Kind() == reflect.Pointer,
+		})
+	}
+	return out
+}
+
+func exportedPackageName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	if pkg == "" {
+		return ""
+	}
+	return strings.ToUpper(pkg[:1]) + pkg[1:]
+}
+
+func (g *generator) sortedDefs() []string {
+	names := make([]string, 0, len(g.defs))
+	for name := range g.defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// jsType は JSDoc 用の型表記を返す。
+func (g *generator) jsType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Pointer:
+		return g.jsType(t.Elem()) + "|null"
+	case reflect.Slice, reflect.Array:
+		return "Array<" + g.jsType(t.Elem()) + ">"
+	case reflect.Map:
+		return "Object<string, " + g.jsType(t.Elem()) + ">"
+	case reflect.Struct:
+		if name, ok := g.names[t]; ok {
+			return name
+		}
+		return "Object"
+	default:
+		return "*"
+	}
+}
+
+// schemaFor は JSON Schema の型定義を返す。
+func (g *generator) schemaFor(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Pointer:
+		return map[string]any{"anyOf": []any{g.schemaFor(t.Elem()), map[string]any{"type": "null"}}}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t == emptyType {
+			return map[string]any{"type": "object", "additionalProperties": false}
+		}
+		if name, ok := g.names[t]; ok {
+			return map[string]any{"$ref": "#/$defs/" + name}
+		}
+		return g.objectSchema(t)
+	default:
+		return map[string]any{}
+	}
+}
+
+func (g *generator) objectSchema(t reflect.Type) map[string]any {
+	props := make(map[string]any)
+	required := []string{}
+	for _, f := range jsonFields(t) {
+		props[f.Name] = g.schemaFor(f.Type)
+		if !f.Optional {
+			required = append(required, f.Name)
+		}
+	}
+	return map[string]any{
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+}
+
+func (g *generator) schema() ([]byte, error) {
+	defs := make(map[string]any, len(g.defs))
+	for name, t := range g.defs {
+		defs[name] = g.objectSchema(t)
+	}
+	actions := make(map[string]any, len(g.actions))
+	for _, a := range g.actions {
+		action := map[string]any{"description": a.Doc}
+		if a.Request != emptyType {
+			action["request"] = g.schemaFor(a.Request)
+		}
+		if a.Response != emptyType {
+			action["response"] = g.schemaFor(a.Response)
+		}
+		actions[a.Name] = action
+	}
+	doc := map[string]any{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "Nudge RPC",
+		"description": "Generated by rpcgen from internal/rpc/api; DO NOT EDIT.",
+		"actions":     actions,
+		"$defs":       defs,
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal schema: %w", err)
+	}
+	return append(b, '\n'), nil
+}
+
+func (g *generator) client() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(header)
+	b.WriteString("// @ts-check\n")
+	for _, name := range g.sortedDefs() {
+		t := g.defs[name]
+		fmt.Fprintf(&b, "\n/**\n * @typedef {Object} %s\n", name)
+		for _, f := range jsonFields(t) {
+			prop := f.Name
+			if f.Optional {
+				prop = "[" + prop + "]"
+			}
+			fmt.Fprintf(&b, " * @property {%s} %s\n", g.jsType(f.Type), prop)
+		}
+		b.WriteString(" */\n")
+	}
+
+	b.WriteString("\n/**\n")
+	b.WriteString(" * RPC 呼び出し関数（action, payload）を受け取り、アクションごとの型付きメソッドを返す。\n")
+	b.WriteString(" * @param {(action: string, payload?: any) => Promise<any>} call\n")
+	b.WriteString(" */\n")
+	b.WriteString("export function createClient(call) {\n  return {\n")
+	for _, a := range g.actions {
+		b.WriteString("    /**\n")
+		if a.Doc != "" {
+			fmt.Fprintf(&b, "     * %s\n", a.Doc)
+		}
+		if a.Request != emptyType {
+			fmt.Fprintf(&b, "     * @param {%s} payload\n", g.jsType(a.Request))
+		}
+		if a.Response != emptyType {
+			fmt.Fprintf(&b, "     * @returns {Promise<%s>}\n", g.jsType(a.Response))
+		} else {
+			b.WriteString("     * @returns {Promise<void>}\n")
+		}
+		b.WriteString("     */\n")
+		if a.Request != emptyType {
+			fmt.Fprintf(&b, "    %s: (payload) => call('%s', payload),\n", a.Name, a.Name)
+		} else {
+			fmt.Fprintf(&b, "    %s: () => call('%s'),\n", a.Name, a.Name)
+		}
+	}
+	b.WriteString("  };\n}\n")
+
+	b.WriteString("\nexport const actions = [\n")
+	for _, a := range g.actions {
+		fmt.Fprintf(&b, "  '%s',\n", a.Name)
+	}
+	b.WriteString("];\n")
+	return b.Bytes(), nil
+}
@@ -0,0 +1,61 @@
+// Command rpcgen は internal/rpc/api のアクション定義から JS クライアントと JSON Schema を生成する。
+//
+//	go run nudge/internal/rpc/rpcgen -out cmd/nudge/assets
+//	go run nudge/internal/rpc/rpcgen -out cmd/nudge/assets -check
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"nudge/internal/rpc/api"
+)
+
+const (
+	clientFileName = "rpc-client.js"
+	schemaFileName = "rpc-schema.json"
+)
+
+func main() {
+	out := flag.String("out", ".", "output directory")
+	check := flag.Bool("check", false, "fail if generated files are out of date instead of writing them")
+	flag.Parse()
+
+	g := newGenerator()
+	for _, action := range api.Actions {
+		g.addAction(action.Spec())
+	}
+	client, err := g.client()
+	if err != nil {
+		fail(err)
+	}
+	schema, err := g.schema()
+	if err != nil {
+		fail(err)
+	}
+	files := map[string][]byte{
+		clientFileName: client,
+		schemaFileName: schema,
+	}
+	for _, name := range []string{clientFileName, schemaFileName} {
+		path := filepath.Join(*out, name)
+		if *check {
+			current, err := os.ReadFile(path)
+			if err != nil || !bytes.Equal(current, files[name]) {
+				fail(fmt.Errorf("%s is out of date; run go generate ./cmd/nudge", path))
+			}
+			continue
+		}
+		if err := os.WriteFile(path, files[name], 0o644); err != nil {
+			fail(err)
+		}
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "rpcgen:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"nudge/internal/dto"
+)
+
+// ActionHistory は Nudge から行った操作を JSON Lines で追記保存する。
+type ActionHistory struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func NewActionHistory(path string) *ActionHistory {
+	return &ActionHistory{Path: path}
+}
+
+func (h *ActionHistory) Append(rec dto.ActionRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal action record: %w", err)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(h.Path), 0o755); err != nil {
+		return fmt.Errorf("mkdir history dir: %w", err)
+	}
+	f, err := os.OpenFile(h.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open history: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("write history: %w", err)
+	}
+	return nil
+}
+
+// Records は履歴を記録順に返す。壊れた行は読み飛ばす。
+func (h *ActionHistory) Records() ([]dto.ActionRecord, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, err := os.Open(h.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open history: %w", err)
+	}
+	defer f.Close()
+	var out []dto.ActionRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec dto.ActionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil || rec.PageID == "" {
+			continue
+		}
+		out = append(out, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,53 @@
+package store
+
+import "sync"
+
+// ActiveTokenStore は選択中のアカウントのトークンを読み書きする TokenStore。
+// 設定プロファイルを切り替えたとき、同じインスタンスを持つ App と Notion クライアントの両方が新しいトークンを使う。
+type ActiveTokenStore struct {
+	base AccountTokenStore
+
+	mu      sync.Mutex
+	account string
+}
+
+func NewActiveTokenStore(base AccountTokenStore) *ActiveTokenStore {
+	return &ActiveTokenStore{base: base}
+}
+
+// UseAccount は以降の読み書きの対象を account のトークンにする。空なら既定のトークン。
+func (s *ActiveTokenStore) UseAccount(account string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.account = account
+}
+
+func (s *ActiveTokenStore) current() TokenStore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.base.ForAccount(s.account)
+}
+
+func (s *ActiveTokenStore) GetToken() (string, error) {
+	return s.current().GetToken()
+}
+
+func (s *ActiveTokenStore) SetToken(token string) error {
+	return s.current().SetToken(token)
+}
+
+func (s *ActiveTokenStore) ClearToken() error {
+	return s.current().ClearToken()
+}
+
+// InvalidateToken は選択中のアカウントのキャッシュを捨てる。元の TokenStore がキャッシュしなければ何もしない。
+func (s *ActiveTokenStore) InvalidateToken() {
+	if invalidator, ok := s.current().(TokenInvalidator); ok {
+		invalidator.InvalidateToken()
+	}
+}
+
+// ForAccount は選択中のアカウントに関係なく account のトークンを扱う TokenStore を返す。
+func (s *ActiveTokenStore) ForAccount(account string) TokenStore {
+	return s.base.ForAccount(account)
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"nudge/internal/dto"
+)
+
+// BrainStore は Brain ウィンドウの下書きと登録履歴を JSON ファイルに保存する。
+type BrainStore struct {
+	DraftPath string
+	NotesPath string
+
+	mu sync.Mutex
+}
+
+func NewBrainStore(draftPath, notesPath string) *BrainStore {
+	return &BrainStore{DraftPath: draftPath, NotesPath: notesPath}
+}
+
+// Drafts はプロファイルのキーごとの下書きを返す。ファイルがなければ空。
+func (s *BrainStore) Drafts() (map[string]dto.BrainDraft, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	drafts := make(map[string]dto.BrainDraft)
+	if err := readJSONFile(s.DraftPath, &drafts); err != nil {
+		return nil, fmt.Errorf("read brain drafts: %w", err)
+	}
+	return drafts, nil
+}
+
+// SaveDrafts は下書きを書き込む。空ならファイルを消す。
+func (s *BrainStore) SaveDrafts(drafts map[string]dto.BrainDraft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(drafts) == 0 {
+		if err := os.Remove(s.DraftPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove brain drafts: %w", err)
+		}
+		return nil
+	}
+	if err := writeJSONFile(s.DraftPath, drafts); err != nil {
+		return fmt.Errorf("write brain drafts: %w", err)
+	}
+	return nil
+}
+
+// Notes は登録履歴を新しい順に返す。
+func (s *BrainStore) Notes() ([]dto.BrainNote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var notes []dto.BrainNote
+	if err := readJSONFile(s.NotesPath, &notes); err != nil {
+		return nil, fmt.Errorf("read brain notes: %w", err)
+	}
+	return notes, nil
+}
+
+// AddNote は履歴の先頭に note を追加し、limit 件を超えた古いものを捨てる。
+func (s *BrainStore) AddNote(note dto.BrainNote, limit int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var notes []dto.BrainNote
+	if err := readJSONFile(s.NotesPath, &notes); err != nil {
+		// 壊れた履歴は作り直す
+		notes = nil
+	}
+	notes = append([]dto.BrainNote{note}, notes...)
+	if limit > 0 && len(notes) > limit {
+		notes = notes[:limit]
+	}
+	if err := writeJSONFile(s.NotesPath, notes); err != nil {
+		return fmt.Errorf("write brain notes: %w", err)
+	}
+	return nil
+}
+
+func readJSONFile(path string, v any) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func writeJSONFile(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o600)
+}
@@ -0,0 +1,83 @@
+package store
+
+import "sync"
+
+// TokenInvalidator はキャッシュしたトークンを捨てられる TokenStore。
+// Notion がトークンを拒否したとき、次の呼び出しで保存先から読み直させるのに使う。
+type TokenInvalidator interface {
+	InvalidateToken()
+}
+
+// CachedTokenStore は読み出したトークンをメモリに保持する TokenStore。
+// Keychain は読み出しのたびに security コマンドを起動するため、DB ごとの API 呼び出しで毎回読まないようにする。
+// SetToken / ClearToken / InvalidateToken でキャッシュを捨てる。見つからなかった結果は保持しない。
+type CachedTokenStore struct {
+	base TokenStore
+
+	mu       sync.Mutex
+	token    string
+	cached   bool
+	accounts map[string]*CachedTokenStore
+}
+
+func NewCachedTokenStore(base TokenStore) *CachedTokenStore {
+	return &CachedTokenStore{base: base}
+}
+
+func (s *CachedTokenStore) GetToken() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached {
+		return s.token, nil
+	}
+	token, err := s.base.GetToken()
+	if err != nil {
+		return "", err
+	}
+	s.token, s.cached = token, true
+	return token, nil
+}
+
+func (s *CachedTokenStore) SetToken(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token, s.cached = "", false
+	if err := s.base.SetToken(token); err != nil {
+		return err
+	}
+	s.token, s.cached = token, true
+	return nil
+}
+
+func (s *CachedTokenStore) ClearToken() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token, s.cached = "", false
+	return s.base.ClearToken()
+}
+
+func (s *CachedTokenStore) InvalidateToken() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token, s.cached = "", false
+}
+
+// ForAccount は account ごとのキャッシュを持つ TokenStore を返す。同じ account には同じインスタンスを返す。
+// 元の TokenStore がアカウントを分けられない場合は s をそのまま返す。
+func (s *CachedTokenStore) ForAccount(account string) TokenStore {
+	accounts, ok := s.base.(AccountTokenStore)
+	if account == "" || !ok {
+		return s
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if child, ok := s.accounts[account]; ok {
+		return child
+	}
+	if s.accounts == nil {
+		s.accounts = make(map[string]*CachedTokenStore)
+	}
+	child := NewCachedTokenStore(accounts.ForAccount(account))
+	s.accounts[account] = child
+	return child
+}
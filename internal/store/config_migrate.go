@@ -0,0 +1,129 @@
+package store
+
+import (
+	"fmt"
+	"os"
+)
+
+// configMigration は設定ファイルを To - 1 版から To 版へ書き換える 1 段分の移行。
+// 型付きの dto.Config からは消えた旧フィールドも扱えるよう、JSON のまま書き換える。
+type configMigration struct {
+	To    int
+	Name  string
+	Apply func(doc map[string]any) error
+}
+
+// configMigrations は版の順に並べた移行の一覧。新しい形式を追加するときは末尾に足し、dto.ConfigVersion を上げる。
+var configMigrations = []configMigration{
+	{To: 1, Name: "single database fields to databases", Apply: migrateSingleDatabase},
+	{To: 2, Name: "brain ids to brain_profiles", Apply: migrateBrainProfiles},
+}
+
+// legacyDatabaseFields は databases 導入前に最上位にあったタスク DB の設定。
+var legacyDatabaseFields = []string{
+	"database_id",
+	"data_source_id",
+	"title_property_name",
+	"status_property_name",
+	"status_property_type",
+	"status_in_progress",
+	"status_done",
+	"status_paused",
+}
+
+// migrateConfig は from 版の doc に以降の移行を順に適用する。
+func migrateConfig(doc map[string]any, from int) error {
+	for _, m := range configMigrations {
+		if m.To <= from {
+			continue
+		}
+		if err := m.Apply(doc); err != nil {
+			return fmt.Errorf("migrate config to v%d (%s): %w", m.To, m.Name, err)
+		}
+		doc["version"] = m.To
+	}
+	return nil
+}
+
+// configVersion は doc の version を返す。version がない設定は版 0 とみなす。
+func configVersion(doc map[string]any) (int, error) {
+	value, ok := doc["version"]
+	if !ok || value == nil {
+		return 0, nil
+	}
+	n, ok := value.(float64)
+	if !ok || n < 0 || n != float64(int(n)) {
+		return 0, fmt.Errorf("invalid config version: %v", value)
+	}
+	return int(n), nil
+}
+
+// backupConfig は移行前の設定を path.v<version>.bak に書き出す。
+func backupConfig(path string, b []byte, version int) error {
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	if err := os.WriteFile(backup, b, 0o600); err != nil {
+		return fmt.Errorf("backup config: %w", err)
+	}
+	return nil
+}
+
+// migrateSingleDatabase は最上位のタスク DB 設定を databases（タスクと無効の習慣の 2 件）に移す。
+func migrateSingleDatabase(doc map[string]any) error {
+	legacy := make(map[string]any, len(legacyDatabaseFields))
+	for _, name := range legacyDatabaseFields {
+		if value, ok := doc[name]; ok {
+			legacy[name] = value
+			delete(doc, name)
+		}
+	}
+	if databases, _ := doc["databases"].([]any); len(databases) > 0 {
+		return nil
+	}
+	if stringField(legacy, "database_id") == "" && stringField(legacy, "data_source_id") == "" && stringField(legacy, "title_property_name") == "" {
+		return nil
+	}
+	task := map[string]any{
+		"key":     "tasks",
+		"name":    "タスク",
+		"kind":    "task",
+		"enabled": true,
+	}
+	for name, value := range legacy {
+		task[name] = value
+	}
+	// 習慣 DB は旧形式になかったため、ID を設定するまで無効の雛形として足す
+	habit := map[string]any{
+		"key":     "habits",
+		"name":    "習慣",
+		"kind":    "habit",
+		"enabled": false,
+	}
+	doc["databases"] = []any{task, habit}
+	return nil
+}
+
+// migrateBrainProfiles は brain_database_id / brain_template_page_id を brain_profiles の先頭に移す。
+func migrateBrainProfiles(doc map[string]any) error {
+	databaseID := stringField(doc, "brain_database_id")
+	templateID := stringField(doc, "brain_template_page_id")
+	delete(doc, "brain_database_id")
+	delete(doc, "brain_template_page_id")
+	if profiles, _ := doc["brain_profiles"].([]any); len(profiles) > 0 {
+		return nil
+	}
+	if databaseID == "" && templateID == "" {
+		return nil
+	}
+	doc["brain_profiles"] = []any{map[string]any{
+		"key":              "brain-1",
+		"name":             "Brain",
+		"database_id":      databaseID,
+		"template_page_id": templateID,
+	}}
+	return nil
+}
+
+func stringField(doc map[string]any, name string) string {
+	s, _ := doc[name].(string)
+	return s
+}
@@ -0,0 +1,238 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nudge/internal/dto"
+)
+
+const (
+	testDatabaseID = "0123456789abcdef0123456789abcdef"
+	testBrainID    = "fedcba9876543210fedcba9876543210"
+	testTemplateID = "00112233445566778899aabbccddeeff"
+)
+
+// newTestConfigStore は一時ディレクトリを設定ディレクトリにした FileConfigStore と、設定ファイルのパスを返す。
+func newTestConfigStore(t *testing.T) (*FileConfigStore, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+	s := NewFileConfigStore("nudge-test")
+	path, err := s.Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return s, path
+}
+
+func writeTestConfig(t *testing.T, path, content string) []byte {
+	t.Helper()
+	b := []byte(content)
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// readTestDoc は書き戻された設定ファイルを JSON のまま読む。
+func readTestDoc(t *testing.T, path string) map[string]any {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func assertBackup(t *testing.T, path string, version int, want []byte) {
+	t.Helper()
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	got, err := os.ReadFile(backup)
+	if err != nil {
+		t.Fatalf("backup %s: %v", filepath.Base(backup), err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("backup %s = %s, want the original file", filepath.Base(backup), got)
+	}
+}
+
+func assertNoBackup(t *testing.T, path string) {
+	t.Helper()
+	matches, err := filepath.Glob(path + ".v*.bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) > 0 {
+		t.Fatalf("unexpected backups: %v", matches)
+	}
+}
+
+func TestLoadMigratesSingleDatabase(t *testing.T) {
+	s, path := newTestConfigStore(t)
+	original := writeTestConfig(t, path, `{
+  "database_id": "`+testDatabaseID+`",
+  "title_property_name": "名前",
+  "status_property_name": "ステータス",
+  "status_done": "完了",
+  "poll_interval_seconds": 120
+}`)
+
+	cfg, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != dto.ConfigVersion {
+		t.Errorf("version = %d, want %d", cfg.Version, dto.ConfigVersion)
+	}
+	if len(cfg.Databases) != 2 {
+		t.Fatalf("databases = %d, want 2", len(cfg.Databases))
+	}
+	task, habit := cfg.Databases[0], cfg.Databases[1]
+	if task.Key != "tasks" || task.Kind != dto.DatabaseKindTask || !task.Enabled {
+		t.Errorf("task database = %+v", task)
+	}
+	if task.DatabaseID != testDatabaseID || task.TitlePropertyName != "名前" || task.StatusPropertyName != "ステータス" || task.StatusDone != "完了" {
+		t.Errorf("task database fields were not moved: %+v", task)
+	}
+	if habit.Key != "habits" || habit.Kind != dto.DatabaseKindHabit {
+		t.Errorf("habit database = %+v", habit)
+	}
+	if cfg.PollIntervalSeconds != 120 {
+		t.Errorf("poll_interval_seconds = %d, want 120", cfg.PollIntervalSeconds)
+	}
+
+	assertBackup(t, path, 0, original)
+	doc := readTestDoc(t, path)
+	if doc["version"] != float64(dto.ConfigVersion) {
+		t.Errorf("written version = %v, want %d", doc["version"], dto.ConfigVersion)
+	}
+	if _, ok := doc["database_id"]; ok {
+		t.Error("legacy database_id was written back")
+	}
+}
+
+func TestLoadMigratesBrainProfiles(t *testing.T) {
+	s, path := newTestConfigStore(t)
+	original := writeTestConfig(t, path, `{
+  "version": 1,
+  "databases": [{"key": "work", "name": "仕事", "kind": "task", "enabled": true, "database_id": "`+testDatabaseID+`"}],
+  "brain_database_id": "`+testBrainID+`",
+  "brain_template_page_id": "`+testTemplateID+`"
+}`)
+
+	cfg, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Databases) != 1 || cfg.Databases[0].Key != "work" {
+		t.Errorf("databases = %+v, want the existing work database only", cfg.Databases)
+	}
+	if len(cfg.BrainProfiles) != 1 {
+		t.Fatalf("brain_profiles = %d, want 1", len(cfg.BrainProfiles))
+	}
+	profile := cfg.BrainProfiles[0]
+	if profile.Key != "brain-1" || profile.DatabaseID != testBrainID || profile.TemplatePageID != testTemplateID {
+		t.Errorf("brain profile = %+v", profile)
+	}
+
+	assertBackup(t, path, 1, original)
+	doc := readTestDoc(t, path)
+	if _, ok := doc["brain_database_id"]; ok {
+		t.Error("legacy brain_database_id was written back")
+	}
+}
+
+func TestLoadSkipsCurrentVersion(t *testing.T) {
+	s, path := newTestConfigStore(t)
+	original := writeTestConfig(t, path, `{
+  "version": `+fmt.Sprint(dto.ConfigVersion)+`,
+  "databases": [{"key": "work", "name": "仕事", "kind": "task", "enabled": true, "database_id": "`+testDatabaseID+`"}],
+  "brain_database_id": "`+testBrainID+`"
+}`)
+
+	cfg, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.BrainProfiles) != 0 {
+		t.Errorf("brain_profiles = %+v, want no migration for the current version", cfg.BrainProfiles)
+	}
+	assertNoBackup(t, path)
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, original) {
+		t.Error("config file at the current version was rewritten")
+	}
+}
+
+func TestLoadRejectsNewerVersion(t *testing.T) {
+	s, path := newTestConfigStore(t)
+	writeTestConfig(t, path, `{"version": 99}`)
+
+	_, err := s.Load()
+	if err == nil || !strings.Contains(err.Error(), "newer than supported") {
+		t.Fatalf("Load error = %v, want newer version error", err)
+	}
+	assertNoBackup(t, path)
+}
+
+func TestMigrateConfigAppliesOnlyLaterSteps(t *testing.T) {
+	doc := map[string]any{
+		"database_id":       testDatabaseID,
+		"brain_database_id": testBrainID,
+	}
+	if err := migrateConfig(doc, 1); err != nil {
+		t.Fatal(err)
+	}
+	if doc["version"] != dto.ConfigVersion {
+		t.Errorf("version = %v, want %d", doc["version"], dto.ConfigVersion)
+	}
+	if _, ok := doc["databases"]; ok {
+		t.Error("v1 migration ran for a v1 config")
+	}
+	if _, ok := doc["brain_profiles"]; !ok {
+		t.Error("v2 migration did not run")
+	}
+}
+
+func TestConfigVersion(t *testing.T) {
+	tests := []struct {
+		doc     string
+		want    int
+		wantErr bool
+	}{
+		{doc: `{}`, want: 0},
+		{doc: `{"version": null}`, want: 0},
+		{doc: `{"version": 2}`, want: 2},
+		{doc: `{"version": "2"}`, wantErr: true},
+		{doc: `{"version": 1.5}`, wantErr: true},
+		{doc: `{"version": -1}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var doc map[string]any
+		if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+			t.Fatal(err)
+		}
+		got, err := configVersion(doc)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("configVersion(%s) = %d, %v; want %d, error %v", tt.doc, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
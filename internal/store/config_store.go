@@ -1,22 +1,47 @@
 package store
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"nudge/internal/dto"
 )
 
 type ConfigStore interface {
 	Load() (dto.Config, error)
-	Save(cfg dto.Config) error
+	// Save は cfg.Revision が現在のファイルと一致するときだけ保存し、保存後の Revision を返す
+	Save(cfg dto.Config) (string, error)
 	Path() (string, error)
 }
 
+// ErrConfigConflict は読み込み後に設定ファイルが別のプロセスや別の画面から書き換えられていて、保存を取りやめたことを表す。
+var ErrConfigConflict = errors.New("config file was changed by another process")
+
+// FileConfigStore は設定を JSON ファイルに保存する。
+// 書き込みは一時ファイルへの書き出しとリネームで行い、ロックファイルで他のプロセス（CLI など）と排他する。
 type FileConfigStore struct {
 	AppName string
+
+	mu sync.Mutex
+	// loaded は最後に読み書きしたときのファイルの状態。Changed がこれと比べて外部からの変更を検出する。
+	loaded     bool
+	loadedHash [sha256.Size]byte
+	// lastMod / lastSize は Changed が中身を読まずに済ませるための直近の stat 結果。
+	lastMod  time.Time
+	lastSize int64
+}
+
+// ConfigWatcher は設定ファイルが外部（手動編集や CLI）で書き換えられたことを検出できる ConfigStore。
+type ConfigWatcher interface {
+	Watch(ctx context.Context, interval time.Duration, onChange func())
 }
 
 func NewFileConfigStore(appName string) *FileConfigStore {
@@ -37,92 +62,202 @@ func (s *FileConfigStore) Load() (dto.Config, error) {
 	if err != nil {
 		return cfg, err
 	}
-	b, err := os.ReadFile(path)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock(path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return cfg, nil
-		}
-		return cfg, fmt.Errorf("read config: %w", err)
-	}
-	var raw struct {
-		Databases           []dto.DatabaseConfig `json:"databases"`
-		DatabaseID          string               `json:"database_id"`
-		DataSourceID        string               `json:"data_source_id"`
-		TitlePropertyName   string               `json:"title_property_name"`
-		StatusPropertyName  string               `json:"status_property_name"`
-		StatusPropertyType  string               `json:"status_property_type"`
-		StatusInProgress    string               `json:"status_in_progress"`
-		StatusDone          string               `json:"status_done"`
-		StatusPaused        string               `json:"status_paused"`
-		PollIntervalSeconds int                  `json:"poll_interval_seconds"`
-		MaxResults          int                  `json:"max_results"`
-		LaunchAtLogin       bool                 `json:"launch_at_login"`
-		TrayIconPath        string               `json:"tray_icon_path"`
-		NotionVersion       string               `json:"notion_version"`
-		BrainDatabaseID     string               `json:"brain_database_id"`
-		BrainTemplatePageID string               `json:"brain_template_page_id"`
-	}
-	if err := json.Unmarshal(b, &raw); err != nil {
+		return cfg, err
+	}
+	defer unlock()
+
+	b, err := readConfigFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	s.loaded = true
+	s.loadedHash = sha256.Sum256(b)
+	if b == nil {
+		return cfg, nil
+	}
+	revision := configRevision(b)
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
 		return cfg, fmt.Errorf("parse config: %w", err)
 	}
+	version, err := configVersion(doc)
+	if err != nil {
+		return cfg, err
+	}
+	if version > dto.ConfigVersion {
+		return cfg, fmt.Errorf("config version %d is newer than supported version %d", version, dto.ConfigVersion)
+	}
+	migrated := version < dto.ConfigVersion
+	if migrated {
+		if err := backupConfig(path, b, version); err != nil {
+			return cfg, err
+		}
+		if err := migrateConfig(doc, version); err != nil {
+			return cfg, err
+		}
+		if b, err = json.Marshal(doc); err != nil {
+			return cfg, fmt.Errorf("marshal migrated config: %w", err)
+		}
+	}
 
-	if len(raw.Databases) > 0 {
-		cfg.Databases = raw.Databases
-	} else if raw.DatabaseID != "" || raw.DataSourceID != "" || raw.TitlePropertyName != "" {
-		cfg.Databases = []dto.DatabaseConfig{
-			{
-				Key:                "tasks",
-				Name:               "タスク",
-				Kind:               dto.DatabaseKindTask,
-				Enabled:            true,
-				DatabaseID:         raw.DatabaseID,
-				DataSourceID:       raw.DataSourceID,
-				TitlePropertyName:  raw.TitlePropertyName,
-				StatusPropertyName: raw.StatusPropertyName,
-				StatusPropertyType: raw.StatusPropertyType,
-				StatusInProgress:   raw.StatusInProgress,
-				StatusDone:         raw.StatusDone,
-				StatusPaused:       raw.StatusPaused,
-			},
-			{
-				Key:     "habits",
-				Name:    "習慣",
-				Kind:    dto.DatabaseKindHabit,
-				Enabled: true,
-			},
+	// 既定値の上に読み込み、ファイルにない項目は既定値のまま残す。
+	// databases は既定の DB の要素と混ざらないよう空にしてから読む（無ければ Normalize が補う）
+	cfg.Databases = nil
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return dto.DefaultConfig(), fmt.Errorf("parse config: %w", err)
+	}
+	cfg = cfg.Normalize()
+	if migrated {
+		// 移行後の形式で書き戻し、次回からは移行しない（元のファイルは backupConfig で退避済み）
+		if revision, err = s.writeLocked(path, cfg); err != nil {
+			return cfg, fmt.Errorf("save migrated config: %w", err)
 		}
 	}
+	cfg.Revision = revision
+	return cfg, nil
+}
 
-	if raw.PollIntervalSeconds > 0 {
-		cfg.PollIntervalSeconds = raw.PollIntervalSeconds
+// Save は設定を保存し、保存後の Revision を返す。
+// cfg.Revision が現在のファイルと違えば（読み込んだ後に書き換えられていれば）ErrConfigConflict を返す。
+func (s *FileConfigStore) Save(cfg dto.Config) (string, error) {
+	path, err := s.Path()
+	if err != nil {
+		return "", err
 	}
-	if raw.MaxResults > 0 {
-		cfg.MaxResults = raw.MaxResults
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock(path)
+	if err != nil {
+		return "", err
 	}
-	cfg.LaunchAtLogin = raw.LaunchAtLogin
-	cfg.TrayIconPath = raw.TrayIconPath
-	if raw.NotionVersion != "" {
-		cfg.NotionVersion = raw.NotionVersion
+	defer unlock()
+
+	current, err := readConfigFile(path)
+	if err != nil {
+		return "", err
+	}
+	if cfg.Revision != configRevision(current) {
+		return "", ErrConfigConflict
 	}
-	cfg.BrainDatabaseID = raw.BrainDatabaseID
-	cfg.BrainTemplatePageID = raw.BrainTemplatePageID
-	return cfg.Normalize(), nil
+	return s.writeLocked(path, cfg)
 }
 
-func (s *FileConfigStore) Save(cfg dto.Config) error {
+// Changed は最後の Load / Save の後に設定ファイルの内容が変わったかを返す。更新時刻とサイズが同じなら読まない。
+func (s *FileConfigStore) Changed() (bool, error) {
 	path, err := s.Path()
 	if err != nil {
-		return err
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded {
+		return false, nil
 	}
+	var mod time.Time
+	var size int64
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		mod, size = info.ModTime(), info.Size()
+	case os.IsNotExist(err):
+		size = -1
+	default:
+		return false, fmt.Errorf("stat config: %w", err)
+	}
+	if mod.Equal(s.lastMod) && size == s.lastSize {
+		return false, nil
+	}
+	s.lastMod, s.lastSize = mod, size
+	b, err := readConfigFile(path)
+	if err != nil {
+		return false, err
+	}
+	return sha256.Sum256(b) != s.loadedHash, nil
+}
+
+// Watch は interval ごとに Changed を確認し、変わっていれば onChange を呼ぶ。ctx が終わるまで戻らない。
+func (s *FileConfigStore) Watch(ctx context.Context, interval time.Duration, onChange func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if changed, err := s.Changed(); err == nil && changed {
+			onChange()
+		}
+	}
+}
+
+func (s *FileConfigStore) lock(path string) (func(), error) {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return fmt.Errorf("mkdir config dir: %w", err)
+		return nil, fmt.Errorf("mkdir config dir: %w", err)
 	}
+	return lockFile(path + ".lock")
+}
+
+// writeLocked は一時ファイルに書き出して fsync してから置き換える。途中で落ちても元のファイルは壊れない。
+func (s *FileConfigStore) writeLocked(path string, cfg dto.Config) (string, error) {
+	cfg.Version = dto.ConfigVersion
+	cfg.Revision = ""
 	b, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
-		return fmt.Errorf("marshal config: %w", err)
+		return "", fmt.Errorf("marshal config: %w", err)
+	}
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, ".config-*.json")
+	if err != nil {
+		return "", fmt.Errorf("create temp config: %w", err)
 	}
-	if err := os.WriteFile(path, b, 0o600); err != nil {
-		return fmt.Errorf("write config: %w", err)
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("write config: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("sync config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("close config: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return "", fmt.Errorf("chmod config: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("replace config: %w", err)
+	}
+	if err := syncDir(dir); err != nil {
+		return "", fmt.Errorf("sync config dir: %w", err)
+	}
+	s.loaded = true
+	s.loadedHash = sha256.Sum256(b)
+	return configRevision(b), nil
+}
+
+// readConfigFile はファイルの内容を返す。ファイルがなければ nil。
+func readConfigFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	return b, nil
+}
+
+// configRevision はファイルの内容から Revision を求める。ファイルがなければ空。
+func configRevision(b []byte) string {
+	if b == nil {
+		return ""
 	}
-	return nil
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,23 @@
+//go:build !unix
+
+package store
+
+import (
+	"fmt"
+	"os"
+)
+
+// lockFile はロックファイルを作るだけで、プロセス間の排他はしない（flock のない環境向け）。
+// 同時書き込みは FileConfigStore の内容ハッシュの確認で検出する。
+func lockFile(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+	return func() { f.Close() }, nil
+}
+
+// syncDir はディレクトリの fsync ができない環境では何もしない。
+func syncDir(string) error {
+	return nil
+}
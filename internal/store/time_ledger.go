@@ -0,0 +1,71 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"nudge/internal/dto"
+)
+
+// TimeLedger は作業時間の開始/終了イベントを JSON Lines で追記保存する。
+type TimeLedger struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func NewTimeLedger(path string) *TimeLedger {
+	return &TimeLedger{Path: path}
+}
+
+func (l *TimeLedger) Append(ev dto.TimeEvent) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("marshal time event: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0o755); err != nil {
+		return fmt.Errorf("mkdir ledger dir: %w", err)
+	}
+	f, err := os.OpenFile(l.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open ledger: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("write ledger: %w", err)
+	}
+	return nil
+}
+
+// Events は台帳の全イベントを記録順に返す。壊れた行は読み飛ばす。
+func (l *TimeLedger) Events() ([]dto.TimeEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.Open(l.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open ledger: %w", err)
+	}
+	defer f.Close()
+	var out []dto.TimeEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ev dto.TimeEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil || ev.PageID == "" {
+			continue
+		}
+		out = append(out, ev)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read ledger: %w", err)
+	}
+	return out, nil
+}
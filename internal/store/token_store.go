@@ -14,6 +14,18 @@ type TokenStore interface {
 	ClearToken() error
 }
 
+// AccountTokenStore はアカウント ID ごとにトークンを分けて保存できる TokenStore。
+type AccountTokenStore interface {
+	TokenStore
+	// ForAccount は account のトークンを読み書きする TokenStore を返す。空なら既定のトークン。
+	ForAccount(account string) TokenStore
+}
+
+// AccountSwitcher は読み書きの対象のアカウントを切り替えられる TokenStore。
+type AccountSwitcher interface {
+	UseAccount(account string)
+}
+
 // KeychainTokenStore は macOS Keychain を使用する。
 type KeychainTokenStore struct {
 	Service string
@@ -24,6 +36,14 @@ func NewKeychainTokenStore(service, account string) *KeychainTokenStore {
 	return &KeychainTokenStore{Service: service, Account: account}
 }
 
+// ForAccount は Keychain のアカウント名に ":<account>" を付けた別の項目を使う TokenStore を返す。
+func (s *KeychainTokenStore) ForAccount(account string) TokenStore {
+	if account == "" {
+		return s
+	}
+	return &KeychainTokenStore{Service: s.Service, Account: s.Account + ":" + account}
+}
+
 func (s *KeychainTokenStore) GetToken() (string, error) {
 	cmd := exec.Command("security", "find-generic-password", "-s", s.Service, "-a", s.Account, "-w")
 	var stderr bytes.Buffer
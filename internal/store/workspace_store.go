@@ -0,0 +1,58 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"nudge/internal/dto"
+)
+
+// WorkspaceStore はトークンのアカウント ID ごとに、接続しているワークスペースの情報を JSON ファイルに保存する。
+// 表示用の情報で秘密ではないため、トークンと違って Keychain には置かない。
+type WorkspaceStore struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func NewWorkspaceStore(path string) *WorkspaceStore {
+	return &WorkspaceStore{Path: path}
+}
+
+// Save は account のワークスペースの情報を書き込む。
+func (s *WorkspaceStore) Save(account string, workspace dto.NotionWorkspace) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspaces := make(map[string]dto.NotionWorkspace)
+	if err := readJSONFile(s.Path, &workspaces); err != nil {
+		// 壊れたファイルは作り直す
+		workspaces = make(map[string]dto.NotionWorkspace)
+	}
+	workspaces[account] = workspace
+	if err := writeJSONFile(s.Path, workspaces); err != nil {
+		return fmt.Errorf("write workspaces: %w", err)
+	}
+	return nil
+}
+
+// Remove は account のワークスペースの情報を消す。残りがなければファイルを消す。
+func (s *WorkspaceStore) Remove(account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspaces := make(map[string]dto.NotionWorkspace)
+	if err := readJSONFile(s.Path, &workspaces); err != nil {
+		workspaces = make(map[string]dto.NotionWorkspace)
+	}
+	delete(workspaces, account)
+	if len(workspaces) == 0 {
+		if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove workspaces: %w", err)
+		}
+		return nil
+	}
+	if err := writeJSONFile(s.Path, workspaces); err != nil {
+		return fmt.Errorf("write workspaces: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultReminderGrace は起動前などで時刻を過ぎたリマインダーを遅れて送ってよい期間。
+	DefaultReminderGrace = time.Hour
+	// firedRetention は送信済みキーを状態ファイルに残す期間。
+	firedRetention = 7 * 24 * time.Hour
+)
+
+// Reminder は指定時刻に 1 回だけ発火する予定。Key が同じものは再登録しても再送しない。
+type Reminder struct {
+	Key   string
+	Kind  string
+	At    time.Time
+	Title string
+	Body  string
+	URL   string
+	// Expires を過ぎたら未送信でも送らない。ゼロ値なら At + DefaultReminderGrace。
+	Expires time.Time
+}
+
+func (r Reminder) expiresAt() time.Time {
+	if r.Expires.IsZero() {
+		return r.At.Add(DefaultReminderGrace)
+	}
+	return r.Expires
+}
+
+// Scheduler は Reminder を時刻順に発火させる。送信済みキーは StatePath に保存し、再起動後も重複送信しない。
+type Scheduler struct {
+	StatePath string
+	Fire      func(ctx context.Context, r Reminder)
+	Logger    *slog.Logger
+
+	mu        sync.Mutex
+	running   bool
+	loaded    bool
+	reminders []Reminder
+	fired     map[string]time.Time
+	wakeCh    chan struct{}
+	stopCh    chan struct{}
+}
+
+type schedulerState struct {
+	Fired map[string]time.Time `json:"fired"`
+}
+
+// Schedule は予定の一覧を置き換える。送信済みのキーは無視される。
+func (s *Scheduler) Schedule(reminders []Reminder) {
+	s.mu.Lock()
+	s.loadLocked()
+	next := make([]Reminder, 0, len(reminders))
+	for _, r := range reminders {
+		if _, done := s.fired[r.Key]; done {
+			continue
+		}
+		next = append(next, r)
+	}
+	sort.Slice(next, func(i, j int) bool { return next[i].At.Before(next[j].At) })
+	s.reminders = next
+	wake := s.wakeCh
+	s.mu.Unlock()
+
+	if wake != nil {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+	s.loadLocked()
+	s.running = true
+	s.wakeCh = make(chan struct{}, 1)
+	s.stopCh = make(chan struct{})
+	wake, stop := s.wakeCh, s.stopCh
+
+	go func() {
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+		for {
+			select {
+			case <-timer.C:
+				s.fireDue(ctx, time.Now())
+			case <-wake:
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(s.untilNext(time.Now()))
+		}
+	}()
+}
+
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.running {
+		return
+	}
+	close(s.stopCh)
+	s.running = false
+	s.wakeCh = nil
+}
+
+// untilNext は次の予定までの待ち時間を返す。予定がなければ長めに待つ（Schedule で起こされる）。
+func (s *Scheduler) untilNext(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.reminders) == 0 {
+		return time.Hour
+	}
+	wait := s.reminders[0].At.Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+func (s *Scheduler) fireDue(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	var due []Reminder
+	passed := 0
+	pending := s.reminders[:0]
+	for _, r := range s.reminders {
+		if r.At.After(now) {
+			pending = append(pending, r)
+			continue
+		}
+		passed++
+		s.fired[r.Key] = now
+		if now.Before(r.expiresAt()) {
+			due = append(due, r)
+		}
+	}
+	s.reminders = pending
+	if passed > 0 {
+		s.pruneLocked(now)
+		if err := s.saveLocked(); err != nil {
+			s.warn("save reminder state failed", err)
+		}
+	}
+	s.mu.Unlock()
+
+	if s.Fire == nil {
+		return
+	}
+	for _, r := range due {
+		s.Fire(ctx, r)
+	}
+}
+
+func (s *Scheduler) pruneLocked(now time.Time) {
+	for key, at := range s.fired {
+		if now.Sub(at) > firedRetention {
+			delete(s.fired, key)
+		}
+	}
+}
+
+func (s *Scheduler) loadLocked() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	s.fired = make(map[string]time.Time)
+	if s.StatePath == "" {
+		return
+	}
+	b, err := os.ReadFile(s.StatePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.warn("read reminder state failed", err)
+		}
+		return
+	}
+	var state schedulerState
+	if err := json.Unmarshal(b, &state); err != nil {
+		s.warn("parse reminder state failed", err)
+		return
+	}
+	for key, at := range state.Fired {
+		s.fired[key] = at
+	}
+}
+
+func (s *Scheduler) saveLocked() error {
+	if s.StatePath == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.StatePath), 0o755); err != nil {
+		return fmt.Errorf("mkdir state dir: %w", err)
+	}
+	b, err := json.MarshalIndent(schedulerState{Fired: s.fired}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal state: %w", err)
+	}
+	if err := os.WriteFile(s.StatePath, b, 0o600); err != nil {
+		return fmt.Errorf("write state: %w", err)
+	}
+	return nil
+}
+
+func (s *Scheduler) warn(msg string, err error) {
+	if s.Logger != nil {
+		s.Logger.Warn(msg, "error", err)
+	}
+}
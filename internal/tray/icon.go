@@ -0,0 +1,116 @@
+package tray
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+// IconState はトレイアイコンの表示状態。
+type IconState int
+
+const (
+	IconNormal IconState = iota
+	// IconBadge は今日の未チェック習慣がある状態。
+	IconBadge
+	// IconError は直近の更新に失敗した状態。
+	IconError
+)
+
+var (
+	badgeColor = color.NRGBA{R: 0xff, G: 0x9f, B: 0x0a, A: 0xff}
+	errorColor = color.NRGBA{R: 0xff, G: 0x3b, B: 0x30, A: 0xff}
+)
+
+// Icons は状態ごとのアイコン画像（PNG）。
+type Icons struct {
+	Normal []byte
+	Badge  []byte
+	Error  []byte
+}
+
+// NewIcons は基本アイコンからバッジ/エラーの派生アイコンを生成する。
+// 派生に失敗した場合は基本アイコンで代用する。
+func NewIcons(base []byte) (Icons, error) {
+	icons := Icons{Normal: base, Badge: base, Error: base}
+	if len(base) == 0 {
+		return icons, nil
+	}
+	badge, err := withDot(base, badgeColor, false)
+	if err != nil {
+		return icons, fmt.Errorf("badge icon: %w", err)
+	}
+	errIcon, err := withDot(base, errorColor, true)
+	if err != nil {
+		return icons, fmt.Errorf("error icon: %w", err)
+	}
+	icons.Badge = badge
+	icons.Error = errIcon
+	return icons, nil
+}
+
+func (i Icons) For(state IconState) []byte {
+	switch state {
+	case IconBadge:
+		return i.Badge
+	case IconError:
+		return i.Error
+	default:
+		return i.Normal
+	}
+}
+
+// withDot はアイコン右上に丸印を描いた PNG を返す。dim が true なら元の絵柄を薄くする。
+func withDot(src []byte, dot color.NRGBA, dim bool) ([]byte, error) {
+	img, err := png.Decode(bytes.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("decode png: %w", err)
+	}
+	bounds := img.Bounds()
+	out := image.NewNRGBA(bounds)
+	draw.Draw(out, bounds, img, bounds.Min, draw.Src)
+	if dim {
+		for i := 3; i < len(out.Pix); i += 4 {
+			out.Pix[i] = uint8(uint16(out.Pix[i]) * 45 / 100)
+		}
+	}
+
+	size := bounds.Dx()
+	if bounds.Dy() < size {
+		size = bounds.Dy()
+	}
+	r := size / 5
+	if r < 2 {
+		r = 2
+	}
+	cx := bounds.Max.X - r - 1
+	cy := bounds.Min.Y + r + 1
+	for y := cy - r; y <= cy+r; y++ {
+		for x := cx - r; x <= cx+r; x++ {
+			dx, dy := x-cx, y-cy
+			if dx*dx+dy*dy <= r*r {
+				out.SetNRGBA(x, y, dot)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, out); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// StateFor は同期結果からアイコン状態を決める。エラーを最優先で表示する。
+func StateFor(lastError string, uncheckedHabits int) IconState {
+	if lastError != "" {
+		return IconError
+	}
+	if uncheckedHabits > 0 {
+		return IconBadge
+	}
+	return IconNormal
+}
@@ -0,0 +1,53 @@
+package tray
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"nudge/internal/dto"
+)
+
+const ellipsis = "…"
+
+// Label は設定と同期結果からメニューバーに表示するラベルを組み立てる。
+func Label(cfg dto.Config, state dto.SyncState) string {
+	switch cfg.TrayLabelMode {
+	case dto.TrayLabelCount:
+		if state.Counts.Tasks == 0 {
+			return ""
+		}
+		return strconv.Itoa(state.Counts.Tasks)
+	case dto.TrayLabelTitle:
+		if state.TopTask == nil {
+			return ""
+		}
+		return Truncate(strings.TrimSpace(state.TopTask.Title), cfg.TrayLabelMaxLength)
+	default:
+		return ""
+	}
+}
+
+// FocusLabel は集中セッションの残り時間を "🍅 24:59"（一時停止中は "⏸ 24:59"）の形で返す。
+func FocusLabel(session dto.FocusSession, now time.Time) string {
+	remaining := int(session.Remaining(now).Round(time.Second) / time.Second)
+	mark := "🍅"
+	if session.Paused {
+		mark = "⏸"
+	}
+	return fmt.Sprintf("%s %02d:%02d", mark, remaining/60, remaining%60)
+}
+
+// Truncate は文字数（rune）で切り詰め、切った場合は末尾に省略記号を付ける。
+func Truncate(value string, max int) string {
+	if max <= 0 || utf8.RuneCountInString(value) <= max {
+		return value
+	}
+	runes := []rune(value)
+	if max == 1 {
+		return ellipsis
+	}
+	return string(runes[:max-1]) + ellipsis
+}